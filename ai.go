@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/fabiomsouto/dfndr/internal/fsm"
+	"github.com/fabiomsouto/dfndr/internal/utils"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// Enemy AI states
+const (
+	aiPatrol  = "patrol"  // Drift around with the swarm
+	aiAmbush  = "ambush"  // Lie in wait for a player flying straight at us
+	aiChase   = "chase"   // Hunt the player down
+	aiAttack  = "attack"  // Commit to a fast straight run at the player
+	aiRetreat = "retreat" // Get away after taking damage
+	aiRegroup = "regroup" // Find the rest of the swarm again
+)
+
+// Enemy AI events, sent by the world
+const (
+	// A nearby enemy was destroyed
+	aiEventAllyDown = "ally_down"
+)
+
+const (
+	detectRange     = 600 // Distance at which patrolling enemies notice the player
+	attackRange     = 250 // Distance at which enemies start an attack run
+	loseRange       = 900 // Distance at which chasing enemies give up
+	safeRange       = 700 // Distance at which retreating enemies feel safe
+	allyAlarmRadius = 400 // Enemies within this distance hear about a destroyed ally
+
+	ambushClosingSpeed = 4   // Player speed towards an enemy that triggers an ambush
+	ambushMaxTicks     = 300 // Frames an enemy lies in wait before giving up
+	attackRunTicks     = 90  // Length of an attack run
+	retreatMaxTicks    = 240 // Longest an enemy keeps running away
+	regroupTicks       = 120 // Time spent regrouping before hunting again
+)
+
+var enemyAI = fsm.NewDefinition(
+	&fsm.State[*Enemy]{
+		Name: aiPatrol,
+		Update: func(e *Enemy, _ int) string {
+			switch {
+			case e.damaged():
+				return aiRetreat
+			case e.playerDistance() > detectRange:
+				return ""
+			case e.playerClosingSpeed() > ambushClosingSpeed:
+				return aiAmbush
+			default:
+				return aiChase
+			}
+		},
+		On: map[string]string{aiEventAllyDown: aiChase},
+	},
+	&fsm.State[*Enemy]{
+		Name: aiAmbush,
+		Update: func(e *Enemy, ticks int) string {
+			switch {
+			case e.damaged():
+				return aiRetreat
+			case e.playerDistance() < attackRange:
+				return aiAttack
+			case e.playerClosingSpeed() < 0 || ticks > ambushMaxTicks:
+				return aiChase
+			}
+			return ""
+		},
+		On: map[string]string{aiEventAllyDown: aiRetreat},
+	},
+	&fsm.State[*Enemy]{
+		Name: aiChase,
+		Update: func(e *Enemy, _ int) string {
+			switch dist := e.playerDistance(); {
+			case e.damaged():
+				return aiRetreat
+			case dist < attackRange:
+				return aiAttack
+			case dist > loseRange:
+				return aiPatrol
+			}
+			return ""
+		},
+		On: map[string]string{aiEventAllyDown: aiRetreat},
+	},
+	&fsm.State[*Enemy]{
+		Name: aiAttack,
+		Enter: func(e *Enemy) {
+			// Lock in a heading at where the player will be
+			target := e.player.agent()
+			target.Pos = target.Pos.Add(target.Vel.Scale(pursuitLookahead))
+			dx, dy := utils.WrapDirection(e.x, e.y, target.Pos.X, target.Pos.Y, WorldWidth)
+			e.attackHeading = math.Atan2(dy, dx)
+		},
+		Update: func(e *Enemy, ticks int) string {
+			switch {
+			case e.damaged():
+				return aiRetreat
+			case ticks > attackRunTicks:
+				return aiRegroup
+			}
+			return ""
+		},
+	},
+	&fsm.State[*Enemy]{
+		Name: aiRetreat,
+		Update: func(e *Enemy, ticks int) string {
+			if e.playerDistance() > safeRange || ticks > retreatMaxTicks {
+				return aiRegroup
+			}
+			return ""
+		},
+	},
+	&fsm.State[*Enemy]{
+		Name: aiRegroup,
+		Update: func(e *Enemy, ticks int) string {
+			switch {
+			case ticks < regroupTicks:
+				return ""
+			case e.playerDistance() < detectRange:
+				return aiChase
+			default:
+				return aiPatrol
+			}
+		},
+	},
+)
+
+// damaged reports whether the enemy was just hit and has lost health
+func (e *Enemy) damaged() bool {
+	return e.hitTimer > 0 && e.health < difficultyLevels[e.diffLevel].hits
+}
+
+func (e *Enemy) playerDistance() float64 {
+	playerX, playerY := e.player.Position()
+	return utils.WrapDistance(e.x, e.y, playerX, playerY, WorldWidth)
+}
+
+// playerClosingSpeed returns how fast the player is flying towards the
+// enemy, negative when flying away
+func (e *Enemy) playerClosingSpeed() float64 {
+	p := e.player.agent()
+	dx, dy := utils.WrapDirection(p.Pos.X, p.Pos.Y, e.x, e.y, WorldWidth)
+	dist := math.Hypot(dx, dy)
+	if dist == 0 {
+		return 0
+	}
+	return (p.Vel.X*dx + p.Vel.Y*dy) / dist
+}
+
+// aiState returns a label for the enemy's current behavior
+func (e *Enemy) aiState() string {
+	if e.path != nil {
+		return "formation"
+	}
+	return e.brain.Current()
+}
+
+// DrawDebug labels the enemy with its AI state and how long it has been in it
+func (e *Enemy) DrawDebug(screen *ebiten.Image) {
+	if !e.active || e.exploding {
+		return
+	}
+	screenX, screenY := e.viewport.WorldToScreen(e.viewport.Lerp(e.prevX, e.prevY, e.x, e.y))
+	label := fmt.Sprintf("%s %d", e.aiState(), e.brain.Ticks())
+	ebitenutil.DebugPrintAt(screen, label, int(screenX), int(screenY)-16)
+}
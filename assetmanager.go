@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"slices"
+
+	"github.com/fabiomsouto/dfndr/internal/atlas"
+	"github.com/fabiomsouto/dfndr/internal/particles"
+	"github.com/fabiomsouto/dfndr/internal/theme"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+const (
+	atlasWidth   = 2048
+	atlasPadding = 1 // Keeps linear filtering from bleeding neighbours into a sprite
+)
+
+// SpriteID names a sprite sheet in the atlas
+type SpriteID int
+
+const (
+	SpriteShip SpriteID = iota
+	SpriteMemleak
+	SpriteLander
+	SpriteMutant
+	SpriteGopher
+	spriteCount
+)
+
+// spriteNames are the IDs theme manifests know each sprite by
+var spriteNames = [spriteCount]string{
+	SpriteShip:    "ship",
+	SpriteMemleak: "memleak",
+	SpriteLander:  "lander",
+	SpriteMutant:  "mutant",
+	SpriteGopher:  "gopher",
+}
+
+// spriteSizes are the frame sizes the game is built around, which every
+// theme's sprites must stick to
+var spriteSizes = [spriteCount]image.Point{
+	SpriteShip:    {shipWidth, shipHeight},
+	SpriteMemleak: {enemyWidth, enemyHeight},
+	SpriteLander:  {enemyWidth, enemyHeight},
+	SpriteMutant:  {enemyWidth, enemyHeight},
+	SpriteGopher:  {gopherWidth, gopherHeight},
+}
+
+func (id SpriteID) String() string {
+	return spriteNames[id]
+}
+
+// AssetManager loads every asset once, up front, and hands them out from
+// then on. Sprites are packed into a single atlas texture. The sprites,
+// palette, font and sounds come from the current theme.
+type AssetManager struct {
+	theme       string
+	atlas       *ebiten.Image
+	sprites     [spriteCount]*SpriteSheet
+	palette     *Palette
+	sounds      map[string][]byte
+	formations  []*Formation
+	backgrounds map[string][]*BackgroundLayer // Layer definitions by file name, not yet populated
+	emitters    map[string]*particles.Emitter
+	font        *text.GoTextFaceSource
+	shaders     map[string]*ebiten.Shader
+}
+
+// LoadAssets loads and validates all the game's assets, in the given theme
+func LoadAssets(t *theme.Theme) (*AssetManager, error) {
+	a := &AssetManager{}
+	if err := a.UseTheme(t); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if a.formations, err = loadFormations(); err != nil {
+		return nil, err
+	}
+	if a.backgrounds, err = loadBackgrounds(); err != nil {
+		return nil, err
+	}
+	if _, ok := a.backgrounds[defaultBackground]; !ok {
+		return nil, fmt.Errorf("missing the %s background", defaultBackground)
+	}
+	if a.emitters, err = loadEmitters(); err != nil {
+		return nil, err
+	}
+	if a.shaders, err = loadShaders(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// UseTheme loads the sprites, palette, font and sounds of a theme. Sprite
+// sheets and the palette are changed in place, so everything holding on
+// to them takes on the new look. If the theme fails to load, nothing
+// changes.
+func (a *AssetManager) UseTheme(t *theme.Theme) error {
+	for name := range t.Sprites {
+		if !slices.Contains(spriteNames[:], name) {
+			return fmt.Errorf("theme %s has unknown sprite %s", t.Name, name)
+		}
+	}
+	atlas, sheets, err := loadSprites(t)
+	if err != nil {
+		return err
+	}
+	palette, err := loadPalette(t.Palettes)
+	if err != nil {
+		return err
+	}
+	fontFile, ok := t.Fonts[hudFont]
+	if !ok {
+		return fmt.Errorf("theme %s has no %s font", t.Name, hudFont)
+	}
+	font, err := loadFont(fontFile)
+	if err != nil {
+		return err
+	}
+	sounds := make(map[string][]byte, len(t.Sounds))
+	for id, f := range t.Sounds {
+		if sounds[id], err = f.Read(); err != nil {
+			return fmt.Errorf("failed to read sound %s: %w", id, err)
+		}
+	}
+
+	a.theme = t.Name
+	a.atlas = atlas
+	for id, sheet := range sheets {
+		if a.sprites[id] == nil {
+			a.sprites[id] = sheet
+		} else {
+			*a.sprites[id] = *sheet
+		}
+	}
+	if a.palette == nil {
+		a.palette = palette
+	} else {
+		*a.palette = *palette
+	}
+	a.font = font
+	a.sounds = sounds
+	return nil
+}
+
+// loadSprites decodes every sprite sheet of a theme and packs them into an atlas
+func loadSprites(t *theme.Theme) (*ebiten.Image, []*SpriteSheet, error) {
+	sheets := make([]*SpriteSheet, spriteCount)
+	images := make([]image.Image, spriteCount)
+	sizes := make([]image.Point, spriteCount)
+	for id := range spriteCount {
+		file, ok := t.Sprites[id.String()]
+		if !ok {
+			return nil, nil, fmt.Errorf("theme %s has no %s sprite", t.Name, id)
+		}
+		sheet, img, err := decodeSpriteSheet(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if frame := image.Pt(sheet.FrameWidth, sheet.FrameHeight); frame != spriteSizes[id] {
+			return nil, nil, fmt.Errorf("%s frames are %v, want %v", id, frame, spriteSizes[id])
+		}
+		sheets[id] = sheet
+		images[id] = img
+		sizes[id] = img.Bounds().Size()
+	}
+
+	rects, size := atlas.Pack(sizes, atlasWidth, atlasPadding)
+	pixels := image.NewRGBA(image.Rectangle{Max: size})
+	for id, img := range images {
+		draw.Draw(pixels, rects[id], img, img.Bounds().Min, draw.Src)
+	}
+	texture := ebiten.NewImageFromImage(pixels)
+	for id, sheet := range sheets {
+		sheet.cut(texture, rects[id])
+	}
+	return texture, sheets, nil
+}
+
+// Theme returns the name of the current theme
+func (a *AssetManager) Theme() string {
+	return a.theme
+}
+
+// Sprite returns a sprite sheet
+func (a *AssetManager) Sprite(id SpriteID) *SpriteSheet {
+	return a.sprites[id]
+}
+
+// Formations returns every scripted attack run
+func (a *AssetManager) Formations() []*Formation {
+	return a.formations
+}
+
+// Background builds the background for a level, falling back to the
+// default look if the level doesn't have its own
+func (a *AssetManager) Background(level int, seed int64, height float64, palette []color.RGBA) *Background {
+	layers, ok := a.backgrounds[fmt.Sprintf("level%d", level)]
+	if !ok {
+		layers = a.backgrounds[defaultBackground]
+	}
+	return newBackground(layers, seed, height, palette)
+}
+
+// Emitters returns every particle effect by name
+func (a *AssetManager) Emitters() map[string]*particles.Emitter {
+	return a.emitters
+}
+
+// Font returns the font the HUD is set in
+func (a *AssetManager) Font() *text.GoTextFaceSource {
+	return a.font
+}
+
+// Palette returns the colors of the current theme
+func (a *AssetManager) Palette() *Palette {
+	return a.palette
+}
+
+// Sound returns the contents of a sound file, if the theme has it. Nothing
+// plays sounds yet, but packs can already ship them.
+func (a *AssetManager) Sound(id string) ([]byte, bool) {
+	data, ok := a.sounds[id]
+	return data, ok
+}
+
+// Shaders returns every post-processing shader by name
+func (a *AssetManager) Shaders() map[string]*ebiten.Shader {
+	return a.shaders
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/fabiomsouto/dfndr/internal/assets"
+	"github.com/fabiomsouto/dfndr/internal/utils"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	backgroundsDir     = "backgrounds"
+	defaultBackground  = "default"
+	backgroundTexSize  = 128
+	backgroundTexEdge  = backgroundTexSize / 2
+	twinkleSpread      = 0.02 // Phase offset between neighbouring twinkling elements
+	backgroundPulseMin = 0.4  // Lowest alpha a pulsing element fades to
+)
+
+// Background layer kinds
+const (
+	layerStars  = "stars"  // Small square points of light
+	layerNebula = "nebula" // Large soft glowing clouds
+	layerPlanet = "planet" // Shaded discs
+	layerDebris = "debris" // Small tumbling chunks
+)
+
+// Background layer animations
+const (
+	animNone    = ""
+	animTwinkle = "twinkle" // Brightness oscillates
+	animPulse   = "pulse"   // Opacity oscillates
+	animDrift   = "drift"   // Elements slowly float sideways
+	animSpin    = "spin"    // Elements rotate
+)
+
+var (
+	// Soft radial falloff, tinted per nebula cloud
+	nebulaTexture = newBackgroundTexture(func(d float64) (float64, float64) {
+		a := math.Max(0, 1-d)
+		return 1, a * a
+	})
+	// Disc lit from the top-left, tinted per planet
+	planetTexture = newBackgroundTexture(nil)
+)
+
+// BackgroundLayer is one depth layer of the background, authored as JSON in
+// internal/assets/backgrounds
+type BackgroundLayer struct {
+	Name      string   `json:"name"`
+	Kind      string   `json:"kind"`
+	Depth     float64  `json:"depth"`    // Larger is further away. Negative layers are drawn in front of the action.
+	Parallax  float64  `json:"parallax"` // How much the layer moves relative to the camera
+	Count     int      `json:"count"`
+	MinSize   float64  `json:"minSize"`
+	MaxSize   float64  `json:"maxSize"`
+	Tint      [4]uint8 `json:"tint"`
+	RandomHue bool     `json:"randomHue"` // Give each element its own hue, scaled by the tint
+	Palette   bool     `json:"palette"`   // Give each element a color from the sector palette, scaled by the tint
+	Animation string   `json:"animation"`
+	Speed     float64  `json:"speed"` // Animation speed, per tick
+
+	elements []backgroundElement
+	// Reused every frame to batch point-like layers into one draw call
+	vertices []ebiten.Vertex
+	indices  []uint16
+}
+
+type backgroundElement struct {
+	x, y  float64
+	size  float64
+	color color.RGBA
+	phase float64 // Animation offset, so elements don't move in lockstep
+}
+
+// Background is the stack of parallax layers behind, and in front of, the action
+type Background struct {
+	layers []*BackgroundLayer // Sorted far to near
+	ticks  int
+}
+
+// loadBackgrounds reads every background definition from the embedded
+// assets, by file name
+func loadBackgrounds() (map[string][]*BackgroundLayer, error) {
+	entries, err := assets.Assets.ReadDir(backgroundsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backgrounds in embedded assets: %w", err)
+	}
+
+	backgrounds := make(map[string][]*BackgroundLayer, len(entries))
+	for _, entry := range entries {
+		data, err := assets.Assets.ReadFile(backgroundsDir + "/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read background %s: %w", entry.Name(), err)
+		}
+		layers, err := parseBackground(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load background %s: %w", entry.Name(), err)
+		}
+		backgrounds[strings.TrimSuffix(entry.Name(), ".json")] = layers
+	}
+	return backgrounds, nil
+}
+
+// parseBackground decodes and validates a background definition, returning
+// its layers sorted far to near
+func parseBackground(data []byte) ([]*BackgroundLayer, error) {
+	var def struct {
+		Layers []*BackgroundLayer `json:"layers"`
+	}
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, err
+	}
+
+	for _, l := range def.Layers {
+		switch l.Kind {
+		case layerStars, layerNebula, layerPlanet, layerDebris:
+		default:
+			return nil, fmt.Errorf("layer %q has unknown kind %q", l.Name, l.Kind)
+		}
+		switch l.Animation {
+		case animNone, animTwinkle, animPulse, animDrift, animSpin:
+		default:
+			return nil, fmt.Errorf("layer %q has unknown animation %q", l.Name, l.Animation)
+		}
+	}
+
+	// Paint far layers first
+	sort.SliceStable(def.Layers, func(i, j int) bool {
+		return def.Layers[i].Depth > def.Layers[j].Depth
+	})
+	return def.Layers, nil
+}
+
+// newBackground scatters the elements of a background's layers over the world
+func newBackground(defs []*BackgroundLayer, seed int64, height float64, palette []color.RGBA) *Background {
+	rng := rand.New(rand.NewSource(seed))
+	layers := make([]*BackgroundLayer, len(defs))
+	for i, def := range defs {
+		l := *def
+		l.populate(rng, height, palette)
+		layers[i] = &l
+	}
+	return &Background{layers: layers}
+}
+
+// populate scatters the layer's elements over a world of the given height
+func (l *BackgroundLayer) populate(rng *rand.Rand, height float64, palette []color.RGBA) {
+	l.elements = make([]backgroundElement, l.Count)
+	for i := range l.elements {
+		clr := color.RGBA{R: l.Tint[0], G: l.Tint[1], B: l.Tint[2], A: l.Tint[3]}
+		switch {
+		case l.Palette && len(palette) > 0:
+			clr = scaleColor(clr, palette[rng.Intn(len(palette))])
+		case l.RandomHue:
+			clr = scaleColor(clr, utils.HSVToRGB(rng.Float64()*360, 0.5, 1))
+		}
+		l.elements[i] = backgroundElement{
+			x:     rng.Float64() * WorldWidth,
+			y:     rng.Float64() * height,
+			size:  l.MinSize + rng.Float64()*(l.MaxSize-l.MinSize),
+			color: clr,
+			phase: rng.Float64() * 2 * math.Pi,
+		}
+	}
+}
+
+// scaleColor multiplies the color channels of clr by those of by, keeping clr's alpha
+func scaleColor(clr, by color.RGBA) color.RGBA {
+	clr.R = uint8(uint16(clr.R) * uint16(by.R) / 255)
+	clr.G = uint8(uint16(clr.G) * uint16(by.G) / 255)
+	clr.B = uint8(uint16(clr.B) * uint16(by.B) / 255)
+	return clr
+}
+
+func (b *Background) Update() {
+	b.ticks++
+	for _, l := range b.layers {
+		if l.Animation != animDrift {
+			continue
+		}
+		for i := range l.elements {
+			l.elements[i].x = utils.Wrap(l.elements[i].x+l.Speed, WorldWidth)
+		}
+	}
+}
+
+// DrawBack draws the layers that sit behind the action
+func (b *Background) DrawBack(screen *ebiten.Image, viewport *Viewport) {
+	for _, l := range b.layers {
+		if l.Depth >= 0 {
+			l.Draw(screen, viewport, b.ticks)
+		}
+	}
+}
+
+// DrawFront draws the layers that pass in front of the action
+func (b *Background) DrawFront(screen *ebiten.Image, viewport *Viewport) {
+	for _, l := range b.layers {
+		if l.Depth < 0 {
+			l.Draw(screen, viewport, b.ticks)
+		}
+	}
+}
+
+func (l *BackgroundLayer) Draw(screen *ebiten.Image, viewport *Viewport, ticks int) {
+	offsetX := viewport.ParallaxX(l.Parallax)
+	offsetY := viewport.ParallaxY(l.Parallax)
+	t := float64(ticks) * l.Speed
+
+	l.vertices = l.vertices[:0]
+	l.indices = l.indices[:0]
+	for i, e := range l.elements {
+		// Wrap horizontally, letting big elements hang off the left edge
+		screenX := utils.Wrap(e.x-offsetX, WorldWidth)
+		if screenX > WorldWidth-e.size {
+			screenX -= WorldWidth
+		}
+		screenY := e.y - offsetY
+		if screenX < -e.size || screenX > viewport.width+e.size ||
+			screenY < -e.size || screenY > viewport.height+e.size {
+			continue
+		}
+
+		clr := e.color
+		switch l.Animation {
+		case animTwinkle:
+			brightness := 0.8 + math.Abs(math.Sin(float64(i)*twinkleSpread+t))*0.4
+			clr.R = scaleChannel(clr.R, brightness)
+			clr.G = scaleChannel(clr.G, brightness)
+			clr.B = scaleChannel(clr.B, brightness)
+		case animPulse:
+			alpha := backgroundPulseMin + (1-backgroundPulseMin)*(0.5+0.5*math.Sin(e.phase+t))
+			clr.A = scaleChannel(clr.A, alpha)
+		}
+
+		switch l.Kind {
+		case layerStars:
+			x, y, s := float32(screenX), float32(screenY), float32(e.size)
+			l.vertices, l.indices = appendQuad(l.vertices, l.indices, x, y, x+s, y, x+s, y+s, x, y+s, clr)
+		case layerDebris:
+			angle := e.phase
+			if l.Animation == animSpin {
+				angle += t
+			}
+			l.vertices, l.indices = appendRotatedQuad(l.vertices, l.indices, screenX, screenY, e.size, angle, clr)
+		case layerNebula:
+			drawBackgroundTexture(screen, nebulaTexture, screenX, screenY, e.size, clr, ebiten.BlendLighter)
+		case layerPlanet:
+			drawBackgroundTexture(screen, planetTexture, screenX, screenY, e.size, clr, ebiten.BlendSourceOver)
+		}
+	}
+
+	if len(l.indices) > 0 {
+		screen.DrawTriangles(l.vertices, l.indices, whitePixel, nil)
+	}
+}
+
+func drawBackgroundTexture(screen, tex *ebiten.Image, x, y, size float64, clr color.RGBA, blend ebiten.Blend) {
+	op := &ebiten.DrawImageOptions{}
+	scale := size / backgroundTexSize
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(x-size/2, y-size/2)
+	// Layer tints are straight alpha, color scales are premultiplied
+	r, g, b, a := float32(clr.R)/255, float32(clr.G)/255, float32(clr.B)/255, float32(clr.A)/255
+	op.ColorScale.Scale(r*a, g*a, b*a, a)
+	op.Blend = blend
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(tex, op)
+}
+
+// newBackgroundTexture renders a white round texture. shade maps the
+// distance from the center (0 at the center, 1 at the rim) to brightness
+// and alpha. A nil shade draws a sphere lit from the top-left.
+func newBackgroundTexture(shade func(d float64) (float64, float64)) *ebiten.Image {
+	img := image.NewRGBA(image.Rect(0, 0, backgroundTexSize, backgroundTexSize))
+	for py := range backgroundTexSize {
+		for px := range backgroundTexSize {
+			dx := (float64(px) + 0.5 - backgroundTexEdge) / backgroundTexEdge
+			dy := (float64(py) + 0.5 - backgroundTexEdge) / backgroundTexEdge
+			d := math.Hypot(dx, dy)
+
+			var brightness, alpha float64
+			if shade != nil {
+				brightness, alpha = shade(d)
+			} else if d <= 1 {
+				// Lambert shading with the light up and to the left
+				z := math.Sqrt(1 - d*d)
+				brightness = math.Max(0.08, -dx*0.5-dy*0.5+z*0.7)
+				alpha = 1
+			}
+			brightness = math.Min(brightness, 1)
+			// Premultiplied alpha
+			v := uint8(255 * brightness * alpha)
+			img.SetRGBA(px, py, color.RGBA{R: v, G: v, B: v, A: uint8(255 * alpha)})
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// scaleChannel multiplies a color channel, saturating at full intensity
+func scaleChannel(c uint8, f float64) uint8 {
+	return uint8(math.Min(255, float64(c)*f))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math"
+
+	"github.com/fabiomsouto/dfndr/internal/utils"
+)
+
+// CameraConfig tunes how the camera tracks the ship
+type CameraConfig struct {
+	Lookahead      float64 // How far ahead of the ship the camera looks, in pixels
+	SpeedLookahead float64 // Extra lookahead per pixel per frame of ship speed
+	SwingSpeed     float64 // Fraction of the way the lookahead swings towards the ship's heading each frame
+	Smoothing      float64 // Fraction of the way the camera catches up with its target each frame
+	Margin         float64 // Closest the ship ever gets to the left or right edge of the screen
+	DeadzoneY      float64 // How far from center the ship can move vertically before the camera follows
+}
+
+var defaultCameraConfig = CameraConfig{
+	Lookahead:      220,
+	SpeedLookahead: 6,
+	SwingSpeed:     0.04,
+	Smoothing:      0.2,
+	Margin:         120,
+	DeadzoneY:      150,
+}
+
+// Camera steers the viewport after the ship. Like in Defender, the ship
+// sits towards one side of the screen so the player sees what's ahead, and
+// the camera swings across when the ship turns around.
+type Camera struct {
+	viewport  *Viewport
+	config    CameraConfig
+	lookahead float64 // Current horizontal offset of the camera from the ship, negative to the left
+}
+
+func NewCamera(viewport *Viewport, config CameraConfig) *Camera {
+	return &Camera{
+		viewport:  viewport,
+		config:    config,
+		lookahead: config.Lookahead,
+	}
+}
+
+// Follow moves the viewport towards the ship
+func (c *Camera) Follow(p *Player) {
+	v := c.viewport
+	shipX, shipY := p.x+shipWidth/2, p.y+shipHeight/2
+
+	// Swing the lookahead over to the side the ship is heading
+	want := c.config.Lookahead + math.Abs(p.vx)*c.config.SpeedLookahead
+	if p.facingLeft {
+		want = -want
+	}
+	c.lookahead += (want - c.lookahead) * c.config.SwingSpeed
+
+	// Ease towards the target, the short way around the world, but never
+	// let the ship slip too close to the edge
+	deltaX := utils.WrapDelta(v.x+v.width/2, shipX+c.lookahead, v.worldWidth)
+	moveX := deltaX * c.config.Smoothing
+	centerX := v.x + v.width/2 + moveX
+	maxOffset := v.width/2 - c.config.Margin
+	if offset := utils.WrapDelta(centerX, shipX, v.worldWidth); offset > maxOffset {
+		moveX += offset - maxOffset
+	} else if offset < -maxOffset {
+		moveX += offset + maxOffset
+	}
+
+	// Vertically, only follow once the ship leaves the deadzone
+	var moveY float64
+	deltaY := shipY - (v.y + v.height/2)
+	if deltaY > c.config.DeadzoneY {
+		moveY = deltaY - c.config.DeadzoneY
+	} else if deltaY < -c.config.DeadzoneY {
+		moveY = deltaY + c.config.DeadzoneY
+	}
+
+	v.Move(moveX, moveY)
+}
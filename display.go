@@ -0,0 +1,95 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// Narrowest and widest aspect ratios the game adapts to. Windows beyond
+	// these get black bars.
+	minAspect = 4.0 / 3
+	maxAspect = 32.0 / 9
+)
+
+// ScaleMode is how the game fills the window, cycled with F10
+type ScaleMode int
+
+const (
+	// ScaleAspect keeps the height of the view and shows more or less of the
+	// world horizontally to match the window's shape
+	ScaleAspect ScaleMode = iota
+	// ScaleInteger draws the classic ScreenWidth x ScreenHeight view, blown
+	// up by whole multiples only so pixels stay crisp
+	ScaleInteger
+	scaleModeCount
+)
+
+func (m ScaleMode) String() string {
+	if m == ScaleInteger {
+		return "integer"
+	}
+	return "aspect"
+}
+
+// Display works out the size of the game's screen from the window, and
+// scales the classic screen up in integer mode
+type Display struct {
+	mode          ScaleMode
+	width, height int           // Size of the game's screen
+	pixels        *ebiten.Image // Fixed size screen for integer scaling
+}
+
+func NewDisplay() *Display {
+	return &Display{width: ScreenWidth, height: ScreenHeight}
+}
+
+// Cycle switches to the next scale mode
+func (d *Display) Cycle() {
+	d.mode = (d.mode + 1) % scaleModeCount
+}
+
+// ToggleFullscreen switches between a window and borderless fullscreen
+func (d *Display) ToggleFullscreen() {
+	ebiten.SetFullscreen(!ebiten.IsFullscreen())
+}
+
+// Layout returns the size of the screen ebiten should hand to Draw for a
+// window of the given size
+func (d *Display) Layout(outsideWidth, outsideHeight int) (int, int) {
+	if d.mode == ScaleInteger {
+		// Draw at window resolution and do the scaling ourselves
+		d.width, d.height = ScreenWidth, ScreenHeight
+		return max(1, outsideWidth), max(1, outsideHeight)
+	}
+	aspect := float64(outsideWidth) / float64(max(1, outsideHeight))
+	aspect = max(minAspect, min(aspect, maxAspect))
+	d.width, d.height = int(math.Round(ScreenHeight*aspect)), ScreenHeight
+	return d.width, d.height
+}
+
+// Screen returns the image the game draws to this frame
+func (d *Display) Screen(screen *ebiten.Image) *ebiten.Image {
+	if d.mode != ScaleInteger {
+		return screen
+	}
+	if d.pixels == nil {
+		d.pixels = ebiten.NewImage(ScreenWidth, ScreenHeight)
+	}
+	d.pixels.Clear()
+	return d.pixels
+}
+
+// Present scales the game's screen up onto the window in integer mode
+func (d *Display) Present(screen, drawn *ebiten.Image) {
+	if drawn == screen {
+		return
+	}
+	size := screen.Bounds().Size()
+	scale := max(1, min(size.X/ScreenWidth, size.Y/ScreenHeight))
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(scale), float64(scale))
+	op.GeoM.Translate(float64(size.X-ScreenWidth*scale)/2, float64(size.Y-ScreenHeight*scale)/2)
+	screen.DrawImage(drawn, op)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	whiteImage = ebiten.NewImage(3, 3)
+
+	// whitePixel is the source image for DrawTriangles calls that only use
+	// vertex colors. Sampling from the middle of a 3x3 image avoids bleeding
+	// at the edges.
+	whitePixel = whiteImage.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+)
+
+func init() {
+	whiteImage.Fill(color.White)
+}
+
+// appendQuad adds a solid quad, given by its corners in order, to a triangle batch
+func appendQuad(vs []ebiten.Vertex, is []uint16, x0, y0, x1, y1, x2, y2, x3, y3 float32, clr color.RGBA) ([]ebiten.Vertex, []uint16) {
+	base := uint16(len(vs))
+	r, g, b, a := float32(clr.R)/255, float32(clr.G)/255, float32(clr.B)/255, float32(clr.A)/255
+	for _, p := range [4][2]float32{{x0, y0}, {x1, y1}, {x2, y2}, {x3, y3}} {
+		vs = append(vs, ebiten.Vertex{
+			DstX: p[0], DstY: p[1],
+			SrcX: 1, SrcY: 1,
+			ColorR: r, ColorG: g, ColorB: b, ColorA: a,
+		})
+	}
+	is = append(is, base, base+1, base+2, base, base+2, base+3)
+	return vs, is
+}
+
+// appendRotatedQuad adds a square of the given size centered on x, y and
+// rotated by angle to a triangle batch
+func appendRotatedQuad(vs []ebiten.Vertex, is []uint16, x, y, size, angle float64, clr color.RGBA) ([]ebiten.Vertex, []uint16) {
+	var corners [4][2]float32
+	sin, cos := math.Sincos(angle)
+	half := size / 2
+	for i, c := range [4][2]float64{{-half, -half}, {half, -half}, {half, half}, {-half, half}} {
+		corners[i] = [2]float32{
+			float32(x + c[0]*cos - c[1]*sin),
+			float32(y + c[0]*sin + c[1]*cos),
+		}
+	}
+	return appendQuad(vs, is,
+		corners[0][0], corners[0][1], corners[1][0], corners[1][1],
+		corners[2][0], corners[2][1], corners[3][0], corners[3][1], clr)
+}
+
+// solidVertex returns a vertex that draws clr when used with whitePixel
+func solidVertex(x, y float32, clr color.RGBA) ebiten.Vertex {
+	return ebiten.Vertex{
+		DstX: x, DstY: y,
+		SrcX: 1, SrcY: 1,
+		ColorR: float32(clr.R) / 255,
+		ColorG: float32(clr.G) / 255,
+		ColorB: float32(clr.B) / 255,
+		ColorA: float32(clr.A) / 255,
+	}
+}
+
+// colorVertices paints every vertex of a batch, as produced by vector.Path,
+// with a solid color for use with whitePixel
+func colorVertices(vs []ebiten.Vertex, clr color.RGBA) {
+	for i := range vs {
+		vs[i] = solidVertex(vs[i].DstX, vs[i].DstY, clr)
+	}
+}
+
+// premultiply converts a straight alpha color to the premultiplied form
+// color.RGBA is supposed to hold
+func premultiply(clr color.RGBA) color.RGBA {
+	a := uint16(clr.A)
+	return color.RGBA{
+		R: uint8(uint16(clr.R) * a / 255),
+		G: uint8(uint16(clr.G) * a / 255),
+		B: uint8(uint16(clr.B) * a / 255),
+		A: clr.A,
+	}
+}
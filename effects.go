@@ -0,0 +1,134 @@
+package main
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// EffectsLevel is the accessibility setting for screen shake, hit-stop and
+// zoom, cycled with F4
+type EffectsLevel int
+
+const (
+	EffectsFull EffectsLevel = iota
+	EffectsReduced
+	EffectsOff
+)
+
+const (
+	maxShakeOffset = 18   // Pixels the screen moves at full trauma
+	maxShakeAngle  = 0.03 // Radians the screen tilts at full trauma
+	traumaDecay    = 0.02 // Trauma lost per frame
+
+	minZoom        = 0.8  // Furthest the camera zooms out
+	zoomSpeedStart = 10   // Ship speed at which the camera starts zooming out
+	zoomEase       = 0.05 // Fraction of the way the zoom moves towards its target each frame
+
+	// Trauma and hit-stop for the things that rattle the camera
+	killTrauma     = 0.15
+	bigKillStop    = 3
+	damageTrauma   = 0.35
+	lifeLostTrauma = 0.8
+	asteroidTrauma = 0.1
+	bombTrauma     = 0.7
+	bombStop       = 6
+	planetTrauma   = 1
+	planetStop     = 20
+)
+
+func (l EffectsLevel) String() string {
+	switch l {
+	case EffectsReduced:
+		return "reduced"
+	case EffectsOff:
+		return "off"
+	}
+	return "full"
+}
+
+// Next returns the setting that follows l when cycling through them
+func (l EffectsLevel) Next() EffectsLevel {
+	return (l + 1) % (EffectsOff + 1)
+}
+
+// scale is how strongly effects play out at this setting
+func (l EffectsLevel) scale() float64 {
+	switch l {
+	case EffectsReduced:
+		return 0.4
+	case EffectsOff:
+		return 0
+	}
+	return 1
+}
+
+// AddTrauma shakes the screen. Trauma adds up to 1 and wears off over time.
+func (v *Viewport) AddTrauma(amount float64) {
+	v.trauma = min(1, v.trauma+amount*v.effects.scale())
+}
+
+// HitStop freezes the simulation for a number of frames
+func (v *Viewport) HitStop(frames int) {
+	v.hitStop = max(v.hitStop, int(float64(frames)*v.effects.scale()))
+}
+
+// Frozen reports whether a hit-stop is holding the simulation
+func (v *Viewport) Frozen() bool {
+	return v.hitStop > 0
+}
+
+// UpdateEffects advances the camera effects by one tick. The shake is a
+// function of the tick count, so the same inputs always shake the same way.
+func (v *Viewport) UpdateEffects(shipSpeed float64) {
+	v.ticks++
+	if v.hitStop > 0 {
+		v.hitStop--
+	}
+	v.trauma = max(0, v.trauma-traumaDecay)
+
+	// Shake grows with the square of trauma, so small hits stay subtle
+	shake := v.trauma * v.trauma
+	t := float64(v.ticks)
+	v.shakeX = maxShakeOffset * shake * shakeNoise(t, 1)
+	v.shakeY = maxShakeOffset * shake * shakeNoise(t, 2)
+	v.shakeAngle = maxShakeAngle * shake * shakeNoise(t, 3)
+
+	// Zoom out as the ship picks up speed, to show more of what's coming
+	zoomOut := max(0, min(1, (shipSpeed-zoomSpeedStart)/(shipMaxSpeed-zoomSpeedStart)))
+	target := 1 - (1-minZoom)*zoomOut*v.effects.scale()
+	v.setZoom(v.zoom + (target-v.zoom)*zoomEase)
+}
+
+// shakeNoise is smooth noise in [-1, 1], a different curve for each seed
+func shakeNoise(t, seed float64) float64 {
+	return (math.Sin(t*0.9+seed*11) + math.Sin(t*1.7+seed*23)*0.5) / 1.5
+}
+
+// setZoom changes how much of the world the viewport shows, around its center
+func (v *Viewport) setZoom(zoom float64) {
+	width, height := v.baseWidth/zoom, v.baseHeight/zoom
+	v.Move((v.width-width)/2, (v.height-height)/2)
+	v.width, v.height, v.zoom = width, height, zoom
+	v.y = max(0, min(v.y, v.worldHeight-v.height))
+}
+
+// Canvas returns the part of the offscreen image the world should be drawn
+// to, the size of what the viewport currently shows
+func (v *Viewport) Canvas(offscreen *ebiten.Image) *ebiten.Image {
+	return offscreen.SubImage(image.Rect(0, 0, int(math.Ceil(v.width)), int(math.Ceil(v.height)))).(*ebiten.Image)
+}
+
+// Present draws the canvas onto the screen, zoomed and shaken
+func (v *Viewport) Present(screen, canvas *ebiten.Image) {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-v.width/2, -v.height/2)
+	op.GeoM.Rotate(v.shakeAngle)
+	op.GeoM.Scale(v.zoom, v.zoom)
+	op.GeoM.Translate(v.baseWidth/2+v.shakeX, v.baseHeight/2+v.shakeY)
+	if v.zoom != 1 || v.shakeAngle != 0 {
+		op.Filter = ebiten.FilterLinear
+	}
+	screen.DrawImage(canvas, op)
+}
@@ -1,15 +1,13 @@
 package main
 
 import (
-	"bytes"
-	"image"
-	"image/color"
-	"log"
 	"math"
 	"math/rand"
 	"time"
 
-	"github.com/fabiomsouto/dfndr/internal/assets"
+	"github.com/fabiomsouto/dfndr/internal/anim"
+	"github.com/fabiomsouto/dfndr/internal/fsm"
+	"github.com/fabiomsouto/dfndr/internal/steering"
 	"github.com/fabiomsouto/dfndr/internal/utils"
 	"github.com/hajimehoshi/ebiten/v2"
 )
@@ -23,6 +21,12 @@ const (
 	wanderFactor  = 0.8 // How much random wandering (decrease for later levels)
 	precisionBase = 0.3 // Base precision in tracking (increase for later levels)
 	updateRate    = 30  // How often to update random movement (frames)
+
+	// Distance to the player at which enemies switch to their attack animation
+	attackAnimRange = 300
+
+	// Frames a destroyed enemy lingers while it blows up
+	explosionFrames = 50
 )
 
 // Difficulty levels (can be adjusted as levels progress)
@@ -41,44 +45,55 @@ var (
 		{speed: baseSpeed, wander: wanderFactor * 0.3, precision: precisionBase * 2.5, hits: 4},       // Level 4: Full speed, very precise
 		{speed: baseSpeed * 1.3, wander: wanderFactor * 0.1, precision: precisionBase * 3.0, hits: 5}, // Level 5: Aggressive!
 	}
-)
 
-type ExplosionParticle struct {
-	x, y     float64 // Position
-	vx, vy   float64 // Velocity
-	size     float64 // Current size
-	rotation float64 // Current rotation
-	hue      float64 // Color hue
-	life     float64 // Remaining life (0.0 to 1.0)
-}
+	// Last ID handed out to an enemy, so the steering behaviors can tell
+	// enemies spawned on the same spot apart
+	lastEnemyID int
+)
 
 type Enemy struct {
+	id            int
 	x, y          float64
+	prevX, prevY  float64 // Position at the previous tick, to draw between ticks
 	vx, vy        float64
-	image         *ebiten.Image
+	sprites       *SpriteSheet
+	anim          anim.Animator
+	kind          *EnemyKind
+	formation     *Formation    // Attack run this enemy entered with, nil for the ambient swarm
+	path          *pathFollower // Set while the enemy is flying its attack run
+	brain         *fsm.Machine[*Enemy]
+	speedScale    float64 // Speed multiplier of the current AI state
+	attackHeading float64 // Heading locked in for the current attack run
+	target        *Gopher // Gopher a lander is going after
+	captive       *Gopher // Gopher a lander is carrying off
 	player        *Player
 	viewport      *Viewport
-	diffLevel     int                 // Current difficulty level
-	wanderAngle   float64             // Current random movement angle
-	updateCounter int                 // Counter for movement updates
-	rng           *rand.Rand          // Per-enemy random number generator
-	health        int                 // Current health points
-	active        bool                // Whether the enemy is alive and active
-	hitTimer      int                 // Timer for hit visual feedback
-	particles     []ExplosionParticle // Explosion particles
-	exploding     bool                // Whether currently exploding
+	library       *AssetManager
+	diffLevel     int        // Current difficulty level
+	wanderAngle   float64    // Current random movement angle
+	updateCounter int        // Counter for movement updates
+	rng           *rand.Rand // Per-enemy random number generator
+	health        int        // Current health points
+	active        bool       // Whether the enemy is alive and active
+	hitTimer      int        // Frames left of the hit flash
+	dying         int        // Frames left of the death animation
+	exploding     bool       // Whether currently exploding
 }
 
-func NewEnemy(x, y, vx, vy float64, player *Player, viewport *Viewport, level int) *Enemy {
+func NewEnemy(x, y, vx, vy float64, kind *EnemyKind, player *Player, viewport *Viewport, level int, library *AssetManager) *Enemy {
 	source := rand.NewSource(time.Now().UnixNano())
-	return &Enemy{
+	lastEnemyID++
+	e := &Enemy{
+		id:            lastEnemyID,
 		x:             x,
 		y:             y,
+		prevX:         x,
+		prevY:         y,
 		vx:            vx,
 		vy:            vy,
 		player:        player,
-		image:         enemyImg(),
 		viewport:      viewport,
+		library:       library,
 		diffLevel:     level,
 		wanderAngle:   rand.Float64() * 2 * math.Pi,
 		updateCounter: 0,
@@ -86,16 +101,30 @@ func NewEnemy(x, y, vx, vy float64, player *Player, viewport *Viewport, level in
 		health:        difficultyLevels[level].hits,
 		active:        true,
 		hitTimer:      0,
-		particles:     make([]ExplosionParticle, 0),
 		exploding:     false,
+		speedScale:    1,
 	}
+	e.setKind(kind)
+	return e
+}
+
+// setKind turns the enemy into the given kind, with a fresh mind
+func (e *Enemy) setKind(kind *EnemyKind) {
+	e.kind = kind
+	e.sprites = e.library.Sprite(kind.sprite)
+	e.brain = fsm.NewMachine(kind.ai, e, kind.initialState)
+	e.anim.Restart(e.sprites.Sheet, "idle")
 }
 
-func (e *Enemy) Update() {
+// Update advances the enemy by one frame. flock holds the steering state of
+// every live enemy, this one included, for the swarm behaviors.
+func (e *Enemy) Update(flock []steering.Agent) {
 	if !e.active && !e.exploding {
 		return
 	}
 
+	e.updateAnimation()
+
 	// Handle explosion if active
 	if e.exploding {
 		e.updateExplosion()
@@ -107,9 +136,24 @@ func (e *Enemy) Update() {
 		e.hitTimer--
 	}
 
+	// Scripted attack runs take over movement until the enemy breaks off
+	if e.path != nil {
+		if e.path.Update(e) {
+			return
+		}
+		// Breaking off from the formation means attacking
+		e.path = nil
+		e.brain.Set(e, aiAttack)
+	}
+
 	// Get current difficulty settings
 	diff := difficultyLevels[e.diffLevel]
 
+	// Let the AI decide what to do, then move the way this kind moves in that state
+	e.brain.Update(e)
+	move := e.kind.moves[e.brain.Current()]
+	e.speedScale = move.speed
+
 	// Update random movement angle periodically
 	e.updateCounter++
 	if e.updateCounter >= updateRate {
@@ -117,48 +161,29 @@ func (e *Enemy) Update() {
 		e.updateCounter = 0
 	}
 
-	// Calculate direction to player
-	playerX, playerY := e.player.Position()
-	dirX := playerX - e.x
-	dirY := playerY - e.y
-	mag := math.Sqrt((dirX * dirX) + (dirY * dirY))
-	if mag > 0 {
-		dirX /= mag
-		dirY /= mag
-	}
-
-	// Calculate wander direction
-	wanderX := math.Cos(e.wanderAngle)
-	wanderY := math.Sin(e.wanderAngle)
-
-	// Combine tracking and wandering based on precision
-	finalDirX := dirX*diff.precision + wanderX*diff.wander
-	finalDirY := dirY*diff.precision + wanderY*diff.wander
-
-	// Normalize final direction
-	finalMag := math.Sqrt(finalDirX*finalDirX + finalDirY*finalDirY)
-	if finalMag > 0 {
-		finalDirX /= finalMag
-		finalDirY /= finalMag
+	// Blend the steering behaviors of this enemy's kind
+	var force steering.Vec
+	for _, rule := range move.steering {
+		force = force.Add(rule.behavior(e, flock).Scale(rule.weight(diff)))
 	}
 
 	// Apply movement
-	e.vx = finalDirX * diff.speed
-	e.vy = finalDirY * diff.speed
+	vel := e.agent().Apply(force, diff.speed*e.speedScale*e.kind.agility)
+	e.vx, e.vy = vel.X, vel.Y
 
 	e.x += e.vx
 	e.y += e.vy
 
 	// Wrap around world edges
-	if e.x < 0 {
-		e.x = WorldWidth
-	} else if e.x > WorldWidth {
-		e.x = 0
-	}
+	e.x = utils.Wrap(e.x, WorldWidth)
+
+	// Stay within the world vertically
 	if e.y < 0 {
-		e.y = ScreenHeight
-	} else if e.y > ScreenHeight {
 		e.y = 0
+		e.vy = math.Max(e.vy, 0)
+	} else if e.y > e.viewport.worldHeight-enemyHeight {
+		e.y = e.viewport.worldHeight - enemyHeight
+		e.vy = math.Min(e.vy, 0)
 	}
 }
 
@@ -168,22 +193,55 @@ func (e *Enemy) Draw(screen *ebiten.Image, viewport *Viewport) {
 		return
 	}
 
-	if !e.active {
-		return // Don't draw if not active
+	if !e.active || e.waiting() {
+		return // Don't draw if not active or not in play yet
 	}
 
+	e.drawSprite(screen)
+}
+
+func (e *Enemy) drawSprite(screen *ebiten.Image) {
 	// Convert world coordinates to screen coordinates
-	screenX, screenY := e.viewport.WorldToScreen(e.x, e.y)
+	screenX, screenY := e.viewport.WorldToScreen(e.viewport.Lerp(e.prevX, e.prevY, e.x, e.y))
 
 	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(screenX, screenY)
+	screen.DrawImage(e.sprites.Frame(&e.anim), op)
+}
 
-	// Flash white when hit
-	if e.hitTimer > 0 {
-		op.ColorScale.Scale(1.5, 1.5, 1.5, 1)
+// updateAnimation picks the clip that matches what the enemy is doing and
+// advances it by one frame
+func (e *Enemy) updateAnimation() {
+	switch {
+	case e.exploding:
+		// The death clip was started by Hit and plays out on its own
+	case e.anim.Playing() == "hit" && !e.anim.Done():
+		// Let the hit flash finish
+	default:
+		playerX, playerY := e.player.Position()
+		if utils.WrapDistance(e.x, e.y, playerX, playerY, WorldWidth) < attackAnimRange {
+			e.anim.Play(e.sprites.Sheet, "attack")
+		} else {
+			e.anim.Play(e.sprites.Sheet, "idle")
+		}
 	}
+	e.anim.Update()
+}
 
-	op.GeoM.Translate(screenX, screenY)
-	screen.DrawImage(e.image, op)
+// agent returns the enemy's state for the steering behaviors
+func (e *Enemy) agent() steering.Agent {
+	return steering.Agent{
+		Pos:      steering.Vec{X: e.x, Y: e.y},
+		Vel:      steering.Vec{X: e.vx, Y: e.vy},
+		MaxSpeed: difficultyLevels[e.diffLevel].speed * e.speedScale,
+		ID:       e.id,
+	}
+}
+
+// waiting reports whether the enemy is still queued at the start of its
+// attack run. Waiting enemies stay out of sight and out of reach.
+func (e *Enemy) waiting() bool {
+	return e.path != nil && e.path.waiting()
 }
 
 // Hit is called when the enemy is hit by a bullet
@@ -193,12 +251,18 @@ func (e *Enemy) Hit() {
 	}
 
 	e.health--
-	e.hitTimer = 5 // Flash for 5 frames
+	e.hitTimer = 5 // Remember the hit for 5 frames
+	e.anim.Restart(e.sprites.Sheet, "hit")
 
 	if e.health <= 0 {
+		// Drop whatever we were carrying off
+		if e.captive != nil {
+			e.captive.Release()
+			e.captive = nil
+		}
 		e.exploding = true
+		e.anim.Restart(e.sprites.Sheet, "death")
 		e.initExplosion()
-		// TODO: Add score
 	}
 }
 
@@ -209,109 +273,37 @@ func (e *Enemy) Bounds() (float64, float64, float64, float64) {
 
 // CheckBulletCollision checks if a bullet hits this enemy
 func (e *Enemy) CheckBulletCollision(bulletX, bulletY float64) bool {
-	if !e.active || e.exploding {
-		return false // Let bullets pass through when not active or during explosion
+	if !e.active || e.exploding || e.waiting() {
+		return false // Let bullets pass through when not active, not in play yet or during explosion
 	}
 
-	// Simple rectangle collision
-	if bulletX >= e.x && bulletX <= e.x+enemyWidth &&
-		bulletY >= e.y && bulletY <= e.y+enemyHeight {
+	// Simple rectangle collision, measured from the enemy so it holds across the world seam
+	dx, dy := utils.WrapDirection(e.x, e.y, bulletX, bulletY, WorldWidth)
+	if dx >= 0 && dx <= enemyWidth &&
+		dy >= 0 && dy <= enemyHeight {
 		e.Hit()
 		return true
 	}
 	return false
 }
 
+// initExplosion starts the death animation. The explosion itself is a
+// particle effect the world sets off.
 func (e *Enemy) initExplosion() {
-	const numParticles = 20
-	e.particles = make([]ExplosionParticle, numParticles)
-
-	// Create particles in a circular pattern
-	for i := range e.particles {
-		angle := e.rng.Float64() * 2 * math.Pi
-		speed := 2 + e.rng.Float64()*3
-
-		e.particles[i] = ExplosionParticle{
-			x:        e.x + float64(enemyWidth)/2,
-			y:        e.y + float64(enemyHeight)/2,
-			vx:       math.Cos(angle) * speed,
-			vy:       math.Sin(angle) * speed,
-			size:     5 + e.rng.Float64()*10,
-			rotation: e.rng.Float64() * math.Pi,
-			hue:      e.rng.Float64() * 60, // Random hue in red-yellow range
-			life:     1.0,
-		}
-	}
+	e.dying = explosionFrames
 }
 
-func (e *Enemy) updateExplosion() bool {
-	allDead := true
-	for i := range e.particles {
-		if e.particles[i].life > 0 {
-			allDead = false
-
-			// Update position
-			e.particles[i].x += e.particles[i].vx
-			e.particles[i].y += e.particles[i].vy
-
-			// Update rotation
-			e.particles[i].rotation += 0.1
-
-			// Fade out
-			e.particles[i].life -= 0.02
-			e.particles[i].size *= 0.98
-		}
-	}
-
-	if allDead {
+func (e *Enemy) updateExplosion() {
+	e.dying--
+	if e.dying <= 0 {
 		e.active = false
 		e.exploding = false
 	}
-
-	return !allDead
 }
 
 func (e *Enemy) drawExplosion(screen *ebiten.Image) {
-	for _, p := range e.particles {
-		if p.life <= 0 {
-			continue
-		}
-
-		screenX, screenY := e.viewport.WorldToScreen(p.x, p.y)
-
-		// Calculate color based on life and hue
-		c := utils.HSVToRGB(p.hue, 1.0, p.life)
-
-		// Create a rotated rectangle
-		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Translate(-p.size/2, -p.size/2) // Center rotation
-		op.GeoM.Rotate(p.rotation)
-		op.GeoM.Translate(screenX, screenY)
-		op.ColorScale.Scale(
-			float32(c.R)/255.0,
-			float32(c.G)/255.0,
-			float32(c.B)/255.0,
-			float32(p.life),
-		)
-
-		// Create and draw the rectangle
-		rect := ebiten.NewImage(int(p.size), int(p.size))
-		rect.Fill(color.White)
-		screen.DrawImage(rect, op)
+	// The death clip burns out underneath the explosion
+	if !e.anim.Done() {
+		e.drawSprite(screen)
 	}
 }
-
-func enemyImg() *ebiten.Image {
-	// load image from embedded filesystem
-	data, err := assets.Assets.ReadFile("memleak.png")
-	if err != nil {
-		log.Fatalf("failed to read ship.png from embedded assets: %v", err)
-	}
-
-	img, _, err := image.Decode(bytes.NewReader(data))
-	if err != nil {
-		log.Fatalf("failed to decode ship image: %v", err)
-	}
-
-	return ebiten.NewImageFromImage(img)
-}
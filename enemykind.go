@@ -0,0 +1,214 @@
+package main
+
+import (
+	"image/color"
+	"maps"
+
+	"github.com/fabiomsouto/dfndr/internal/fsm"
+	"github.com/fabiomsouto/dfndr/internal/steering"
+)
+
+const (
+	// How many frames ahead enemies predict the player's position
+	pursuitLookahead = 30
+	// Distances, in pixels, at which swarm members react to each other
+	separationRadius = 70
+	flockRadius      = 250
+	regroupRadius    = 600
+)
+
+// EnemyKind describes how a family of enemies looks, thinks and moves. For
+// each AI state a kind blends a list of weighted steering behaviors, so new
+// kinds can mix and match seek, pursue, flocking and so on without touching
+// Enemy.Update.
+type EnemyKind struct {
+	name         string
+	sprite       SpriteID
+	wireframe    wireframe  // Outline in the vector render mode
+	scannerColor color.RGBA // Blip color on the long-range scanner
+	points       int        // Score for destroying one
+	ai           *fsm.Definition[*Enemy]
+	initialState string
+	agility      float64             // Max steering force as a fraction of top speed
+	moves        map[string]movement // Movement for each AI state
+	// Altitude above the planet surface the kind hugs, 0 for free flyers
+	groundHover float64
+	// Whether the kind goes after gophers
+	abducts bool
+	// Whether killing one is a big moment, worth freezing the action for
+	big bool
+}
+
+// movement is how an enemy kind moves while in one AI state
+type movement struct {
+	steering []steeringRule
+	speed    float64 // Multiplier on the difficulty level's speed
+}
+
+// steeringRule is one weighted behavior in an enemy kind's blend. The
+// weight is derived from the current difficulty level, so kinds can become
+// more precise as the game progresses.
+type steeringRule struct {
+	behavior steerFunc
+	weight   func(diff DifficultyLevel) float64
+}
+
+// steerFunc computes a steering force for an enemy given the rest of the swarm
+type steerFunc func(e *Enemy, flock []steering.Agent) steering.Vec
+
+var worldSpace = steering.Space{Width: WorldWidth}
+
+var (
+	// Memleaks hunt the player in loose swarms, with plenty of random drift
+	memleakKind = &EnemyKind{
+		name:         "memleak",
+		sprite:       SpriteMemleak,
+		wireframe:    memleakWireframe,
+		scannerColor: color.RGBA{R: 255, G: 60, B: 60, A: 255},
+		points:       150,
+		ai:           enemyAI,
+		initialState: aiPatrol,
+		agility:      0.08,
+		moves: map[string]movement{
+			aiPatrol: {speed: 0.6, steering: []steeringRule{
+				{behavior: wander, weight: fixedWeight(1)},
+				{behavior: separate, weight: fixedWeight(1.5)},
+				{behavior: align, weight: fixedWeight(0.5)},
+				{behavior: cohere, weight: fixedWeight(0.3)},
+			}},
+			aiAmbush: {speed: 0.3, steering: []steeringRule{
+				{behavior: brake, weight: fixedWeight(1)},
+				{behavior: separate, weight: fixedWeight(1.5)},
+			}},
+			aiChase: {speed: 1, steering: []steeringRule{
+				{behavior: pursuePlayer, weight: func(d DifficultyLevel) float64 { return d.precision }},
+				{behavior: wander, weight: func(d DifficultyLevel) float64 { return d.wander }},
+				{behavior: separate, weight: fixedWeight(1.5)},
+				{behavior: align, weight: fixedWeight(0.3)},
+				{behavior: cohere, weight: fixedWeight(0.2)},
+			}},
+			aiAttack: {speed: 2, steering: []steeringRule{
+				{behavior: dash, weight: fixedWeight(1)},
+			}},
+			aiRetreat: {speed: 1.4, steering: []steeringRule{
+				{behavior: fleePlayer, weight: fixedWeight(1)},
+				{behavior: separate, weight: fixedWeight(0.8)},
+			}},
+			aiRegroup: {speed: 0.8, steering: []steeringRule{
+				{behavior: regroup, weight: fixedWeight(1)},
+				{behavior: separate, weight: fixedWeight(1.5)},
+				{behavior: align, weight: fixedWeight(0.5)},
+			}},
+		},
+	}
+)
+
+var (
+	// Crawlers are memleaks that seep along the planet surface
+	crawlerKind = &EnemyKind{
+		name:         "crawler",
+		sprite:       SpriteMemleak,
+		wireframe:    crawlerWireframe,
+		scannerColor: color.RGBA{R: 255, G: 150, B: 40, A: 255},
+		points:       100,
+		ai:           enemyAI,
+		initialState: aiPatrol,
+		agility:      memleakKind.agility,
+		moves:        memleakKind.moves,
+		groundHover:  10,
+	}
+
+	// Landers swoop down on gophers and carry them off
+	landerKind = &EnemyKind{
+		name:         "lander",
+		sprite:       SpriteLander,
+		wireframe:    landerWireframe,
+		scannerColor: color.RGBA{R: 80, G: 255, B: 80, A: 255},
+		points:       150,
+		ai:           landerAI,
+		initialState: aiHunt,
+		agility:      0.1,
+		abducts:      true,
+		moves: map[string]movement{
+			aiHunt: {speed: 1.2, steering: []steeringRule{
+				{behavior: huntTarget, weight: fixedWeight(1)},
+				{behavior: separate, weight: fixedWeight(1)},
+			}},
+			aiAbduct: {speed: 0.5, steering: []steeringRule{
+				{behavior: ascend, weight: fixedWeight(1)},
+			}},
+		},
+	}
+
+	// Mutants are what landers become once they make off with a gopher.
+	// They go straight for the player, fast and relentless.
+	mutantKind = &EnemyKind{
+		name:         "mutant",
+		sprite:       SpriteMutant,
+		wireframe:    mutantWireframe,
+		scannerColor: color.RGBA{R: 230, G: 60, B: 255, A: 255},
+		points:       150,
+		big:          true,
+		ai:           enemyAI,
+		initialState: aiChase,
+		agility:      0.15,
+		moves: withMoves(memleakKind.moves, map[string]movement{
+			aiChase: {speed: 1.8, steering: []steeringRule{
+				{behavior: pursuePlayer, weight: fixedWeight(1)},
+				{behavior: wander, weight: fixedWeight(0.3)},
+				{behavior: separate, weight: fixedWeight(1)},
+			}},
+		}),
+	}
+)
+
+// withMoves returns a copy of base with the movement of some states replaced
+func withMoves(base, overrides map[string]movement) map[string]movement {
+	moves := maps.Clone(base)
+	maps.Copy(moves, overrides)
+	return moves
+}
+
+func fixedWeight(w float64) func(DifficultyLevel) float64 {
+	return func(DifficultyLevel) float64 { return w }
+}
+
+func pursuePlayer(e *Enemy, _ []steering.Agent) steering.Vec {
+	return steering.Pursue(worldSpace, e.agent(), e.player.agent(), pursuitLookahead)
+}
+
+func fleePlayer(e *Enemy, _ []steering.Agent) steering.Vec {
+	return steering.Flee(worldSpace, e.agent(), e.player.agent().Pos)
+}
+
+func wander(e *Enemy, _ []steering.Agent) steering.Vec {
+	return steering.Wander(e.agent(), e.wanderAngle)
+}
+
+// dash flies straight along the heading locked in when the attack run began
+func dash(e *Enemy, _ []steering.Agent) steering.Vec {
+	return steering.Wander(e.agent(), e.attackHeading)
+}
+
+// brake brings the enemy to a halt
+func brake(e *Enemy, _ []steering.Agent) steering.Vec {
+	a := e.agent()
+	return steering.Arrive(worldSpace, a, a.Pos, 1)
+}
+
+func separate(e *Enemy, flock []steering.Agent) steering.Vec {
+	return steering.Separation(worldSpace, e.agent(), flock, separationRadius)
+}
+
+func align(e *Enemy, flock []steering.Agent) steering.Vec {
+	return steering.Alignment(worldSpace, e.agent(), flock, flockRadius)
+}
+
+func cohere(e *Enemy, flock []steering.Agent) steering.Vec {
+	return steering.Cohesion(worldSpace, e.agent(), flock, flockRadius)
+}
+
+// regroup pulls scattered enemies back together from further away than cohere
+func regroup(e *Enemy, flock []steering.Agent) steering.Vec {
+	return steering.Cohesion(worldSpace, e.agent(), flock, regroupRadius)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fabiomsouto/dfndr/internal/assets"
+	"github.com/fabiomsouto/dfndr/internal/path"
+	"github.com/fabiomsouto/dfndr/internal/steering"
+	"github.com/fabiomsouto/dfndr/internal/utils"
+)
+
+const (
+	formationsDir = "formations"
+	// Distance from its slot at which a member starts slowing down
+	slotArriveRadius = 80
+)
+
+// Formation is a scripted attack run, authored as JSON in
+// internal/assets/formations. Members enter one after another along a
+// spline, settle into slots around the end of the path, hold there, then
+// break off one at a time to attack.
+type Formation struct {
+	Name          string       `json:"name"`
+	Spline        string       `json:"spline"`        // "bezier" or "catmull-rom"
+	Path          [][2]float64 `json:"path"`          // Control points, relative to the viewport's top-left corner
+	Speed         float64      `json:"speed"`         // Pixels per frame along the path
+	Spacing       float64      `json:"spacing"`       // Path distance between consecutive members
+	Slots         [][2]float64 `json:"slots"`         // Offsets from the end of the path, one per member
+	Hold          int          `json:"hold"`          // Frames to hold once the last member is in place
+	BreakInterval int          `json:"breakInterval"` // Frames between members breaking off
+
+	curve *path.Curve
+}
+
+// loadFormations reads every formation definition from the embedded assets
+func loadFormations() ([]*Formation, error) {
+	entries, err := assets.Assets.ReadDir(formationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list formations in embedded assets: %w", err)
+	}
+
+	formations := make([]*Formation, 0, len(entries))
+	for _, entry := range entries {
+		data, err := assets.Assets.ReadFile(formationsDir + "/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read formation %s: %w", entry.Name(), err)
+		}
+		f, err := parseFormation(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load formation %s: %w", entry.Name(), err)
+		}
+		formations = append(formations, f)
+	}
+	return formations, nil
+}
+
+func parseFormation(data []byte) (*Formation, error) {
+	var f Formation
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if len(f.Slots) == 0 {
+		return nil, fmt.Errorf("formation %q has no slots", f.Name)
+	}
+	if f.Speed <= 0 {
+		return nil, fmt.Errorf("formation %q needs a positive speed", f.Name)
+	}
+
+	ctrl := make([]path.Point, len(f.Path))
+	for i, p := range f.Path {
+		ctrl[i] = path.Point{X: p[0], Y: p[1]}
+	}
+	curve, err := path.New(f.Spline, ctrl)
+	if err != nil {
+		return nil, fmt.Errorf("formation %q: %w", f.Name, err)
+	}
+	f.curve = curve
+	return &f, nil
+}
+
+// Spawn creates the members of an attack run, with the path anchored to
+// the viewport's current position
+func (f *Formation) Spawn(kind *EnemyKind, player *Player, viewport *Viewport, level int, library *AssetManager) []*Enemy {
+	originX, originY := viewport.ScreenToWorld(0, 0)
+	end := f.curve.At(f.curve.Length())
+
+	// Everyone holds until the last member has had time to reach its slot
+	lastArrival := (f.curve.Length() + float64(len(f.Slots)-1)*f.Spacing) / f.Speed
+
+	members := make([]*Enemy, len(f.Slots))
+	for i, slot := range f.Slots {
+		e := NewEnemy(originX, originY, 0, 0, kind, player, viewport, level, library)
+		e.formation = f
+		e.path = &pathFollower{
+			curve:    f.curve,
+			originX:  originX,
+			originY:  originY,
+			distance: -float64(i) * f.Spacing,
+			speed:    f.Speed,
+			slotX:    originX + end.X + slot[0],
+			slotY:    originY + end.Y + slot[1],
+			breakIn:  int(lastArrival) + f.Hold + i*f.BreakInterval,
+		}
+		e.path.place(e)
+		e.prevX, e.prevY = e.x, e.y // Don't draw it coming from the corner of the screen
+		members[i] = e
+	}
+	return members
+}
+
+// pathFollower moves an enemy through a formation's attack run, taking
+// over from its steering behaviors until it breaks off
+type pathFollower struct {
+	curve            *path.Curve
+	originX, originY float64 // World position of the path's origin
+	distance         float64 // Distance along the path, negative while waiting to enter
+	speed            float64
+	slotX, slotY     float64 // World position of this member's formation slot
+	breakIn          int     // Frames left until this member breaks off
+}
+
+// Update moves the enemy one frame along its run. It returns false once
+// the enemy has broken off and should go back to its normal behavior.
+func (f *pathFollower) Update(e *Enemy) bool {
+	f.breakIn--
+	if f.breakIn <= 0 {
+		return false
+	}
+
+	f.distance += f.speed
+	if f.distance <= f.curve.Length() {
+		prevX, prevY := e.x, e.y
+		f.place(e)
+		e.vx = utils.WrapDelta(prevX, e.x, WorldWidth)
+		e.vy = e.y - prevY
+		return true
+	}
+
+	// Past the end of the path, settle into the formation slot
+	a := e.agent()
+	a.MaxSpeed = f.speed
+	force := steering.Arrive(worldSpace, a, steering.Vec{X: f.slotX, Y: f.slotY}, slotArriveRadius)
+	vel := a.Apply(force, f.speed)
+	e.vx, e.vy = vel.X, vel.Y
+	e.x = utils.Wrap(e.x+e.vx, WorldWidth)
+	e.y += e.vy
+	return true
+}
+
+// waiting reports whether the member is still queued to enter the run
+func (f *pathFollower) waiting() bool {
+	return f.distance < 0
+}
+
+// place puts the enemy at its current distance along the path
+func (f *pathFollower) place(e *Enemy) {
+	p := f.curve.At(f.distance)
+	e.x = utils.Wrap(f.originX+p.X, WorldWidth)
+	e.y = f.originY + p.Y
+}
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestSpawnStartsMembersOnTheirPath(t *testing.T) {
+	pack, err := loadTheme("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	library, err := LoadAssets(pack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewport := NewViewport(ScreenWidth, ScreenHeight, WorldWidth, defaultWorldHeight)
+	player := NewPlayer(viewport, library)
+	for _, f := range library.Formations() {
+		for i, e := range f.Spawn(memleakKind, player, viewport, 1, library) {
+			if e.prevX != e.x || e.prevY != e.y {
+				t.Errorf("%s member %d starts drawing from (%v, %v), want its position (%v, %v)", f.Name, i, e.prevX, e.prevY, e.x, e.y)
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"math/rand"
+
+	"github.com/fabiomsouto/dfndr/internal/anim"
+	"github.com/fabiomsouto/dfndr/internal/utils"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	gopherWidth  = 18
+	gopherHeight = 22
+
+	gopherWalkSpeed = 0.3
+	gopherTurnOdds  = 300 // One in this many frames a walking gopher turns around
+	gopherGravity   = 0.15
+	gopherMaxFall   = 6
+	safeFallHeight  = 150 // Gophers survive falls shorter than this
+
+	rescueBonus  = 500 // Points for carrying a gopher back to the ground
+	landingBonus = 250 // Points for a gopher that survives a fall on its own
+)
+
+type gopherState int
+
+const (
+	gopherWalking  gopherState = iota // Strolling along the planet surface
+	gopherAbducted                    // Dangling beneath a lander
+	gopherFalling                     // Dropped by a lander that was shot down
+	gopherCarried                     // Caught by the ship, on its way down
+	gopherDead
+)
+
+// Gopher is one of the stranded gophers the player has to protect
+type Gopher struct {
+	x, y     float64 // Top-left corner in world coordinates
+	prevX    float64 // Position at the previous tick, to draw between ticks
+	prevY    float64
+	vy       float64
+	dir      float64 // Walking direction, -1 or 1
+	state    gopherState
+	fallFrom float64 // World y where the current fall began
+	abductor *Enemy  // Lander carrying this gopher off
+	hunter   *Enemy  // Lander that has picked this gopher as its next victim
+	sprites  *SpriteSheet
+	anim     anim.Animator
+	viewport *Viewport
+}
+
+func NewGopher(x, y float64, viewport *Viewport, sprites *SpriteSheet) *Gopher {
+	dir := 1.0
+	if rand.Intn(2) == 0 {
+		dir = -1
+	}
+	g := &Gopher{
+		x:        x,
+		y:        y,
+		prevX:    x,
+		prevY:    y,
+		dir:      dir,
+		state:    gopherWalking,
+		sprites:  sprites,
+		viewport: viewport,
+	}
+	g.anim.Play(g.sprites.Sheet, "walk")
+	return g
+}
+
+// Alive reports whether the gopher can still be saved
+func (g *Gopher) Alive() bool {
+	return g.state != gopherDead
+}
+
+// claimedBy reports whether another live lander is already going after this gopher
+func (g *Gopher) claimedBy(e *Enemy) bool {
+	h := g.hunter
+	return h != nil && h != e && h.active && !h.exploding && h.target == g
+}
+
+// walk moves the gopher along the surface at groundY
+func (g *Gopher) walk(groundY float64) {
+	if rand.Intn(gopherTurnOdds) == 0 {
+		g.dir = -g.dir
+	}
+	g.x = utils.Wrap(g.x+g.dir*gopherWalkSpeed, WorldWidth)
+	g.y = groundY - gopherHeight
+}
+
+// hangFrom keeps an abducted gopher dangling beneath its abductor
+func (g *Gopher) hangFrom(e *Enemy) {
+	g.x = utils.Wrap(e.x+(enemyWidth-gopherWidth)/2, WorldWidth)
+	g.y = e.y + enemyHeight
+}
+
+// ride keeps a caught gopher hanging beneath the ship
+func (g *Gopher) ride(p *Player) {
+	g.x = utils.Wrap(p.x+(shipWidth-gopherWidth)/2, WorldWidth)
+	g.y = p.y + shipHeight
+}
+
+// Grab starts an abduction
+func (g *Gopher) Grab(e *Enemy) {
+	g.state = gopherAbducted
+	g.abductor = e
+	g.hunter = nil
+	g.anim.Play(g.sprites.Sheet, "panic")
+}
+
+// Release drops the gopher from wherever it is
+func (g *Gopher) Release() {
+	g.state = gopherFalling
+	g.abductor = nil
+	g.vy = 0
+	g.fallFrom = g.y
+}
+
+// fall applies gravity to a falling gopher
+func (g *Gopher) fall() {
+	g.vy += gopherGravity
+	if g.vy > gopherMaxFall {
+		g.vy = gopherMaxFall
+	}
+	g.y += g.vy
+}
+
+// land puts the gopher back on its feet on the surface
+func (g *Gopher) land(groundY float64) {
+	g.state = gopherWalking
+	g.vy = 0
+	g.y = groundY - gopherHeight
+	g.anim.Play(g.sprites.Sheet, "walk")
+}
+
+// Kill marks the gopher as lost
+func (g *Gopher) Kill() {
+	g.state = gopherDead
+	g.abductor = nil
+	g.hunter = nil
+}
+
+func (g *Gopher) Draw(screen *ebiten.Image) {
+	if !g.Alive() {
+		return
+	}
+	screenX, screenY := g.viewport.WorldToScreen(g.viewport.Lerp(g.prevX, g.prevY, g.x, g.y))
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(screenX, screenY)
+	screen.DrawImage(g.sprites.Frame(&g.anim), op)
+}
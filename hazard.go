@@ -0,0 +1,251 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+
+	"github.com/fabiomsouto/dfndr/internal/utils"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	asteroidPoints    = 11 // Corners of an asteroid's outline
+	asteroidMinRadius = 14 // Asteroids smaller than this crumble instead of splitting
+	asteroidSplit     = 0.6
+	asteroidMaxDrift  = 0.8
+	asteroidDamage    = 0.8 // Shield damage per pixel of asteroid radius
+	asteroidScore     = 50
+
+	wellCoreDamage = 0.6 // Shield damage per frame inside a well's core
+	wellDots       = 48  // Particles orbiting each well
+)
+
+// hazardPlan is how many hazards of each type a level gets
+type hazardPlan struct {
+	asteroids int
+	wells     int
+}
+
+// hazardPlans holds the plan of each level, starting at level 1. Levels
+// past the end use the last one.
+var hazardPlans = []hazardPlan{
+	{asteroids: 6, wells: 0},
+	{asteroids: 8, wells: 1},
+	{asteroids: 10, wells: 2},
+	{asteroids: 12, wells: 2},
+	{asteroids: 14, wells: 3},
+}
+
+// Asteroid is a destructible rock drifting through the world
+type Asteroid struct {
+	x, y     float64 // Center in world coordinates
+	vx, vy   float64
+	radius   float64
+	rotation float64
+
+	// Position and rotation at the previous tick, to draw between ticks
+	prevX, prevY float64
+	prevRotation float64
+
+	spin   float64
+	shape  [asteroidPoints]float64 // Radius multiplier of each outline corner
+	active bool
+}
+
+func NewAsteroid(x, y, radius float64, rng *rand.Rand) *Asteroid {
+	a := &Asteroid{
+		x:      x,
+		y:      y,
+		prevX:  x,
+		prevY:  y,
+		vx:     (rng.Float64()*2 - 1) * asteroidMaxDrift,
+		vy:     (rng.Float64()*2 - 1) * asteroidMaxDrift,
+		radius: radius,
+		spin:   (rng.Float64()*2 - 1) * 0.02,
+		active: true,
+	}
+	for i := range a.shape {
+		a.shape[i] = 0.7 + rng.Float64()*0.3
+	}
+	return a
+}
+
+// GravityWell pulls the ship and its bullets towards its core
+type GravityWell struct {
+	x, y     float64 // Center in world coordinates
+	radius   float64 // Reach of the pull
+	core     float64 // Radius of the core that damages the ship
+	strength float64 // Pull at the core, in pixels per frame squared
+}
+
+// Pull returns the acceleration the well applies to something at x, y.
+// It fades linearly to nothing at the well's radius.
+func (w *GravityWell) Pull(x, y float64) (float64, float64) {
+	dx, dy := utils.WrapDirection(x, y, w.x, w.y, WorldWidth)
+	d := math.Hypot(dx, dy)
+	if d >= w.radius || d == 0 {
+		return 0, 0
+	}
+	a := w.strength * (1 - d/w.radius)
+	return dx / d * a, dy / d * a
+}
+
+// updateHazards moves the asteroids and applies the pull of the gravity wells
+func (world *World) updateHazards() {
+	p := world.player
+	shipX, shipY := p.x+shipWidth/2, p.y+shipHeight/2
+
+	for _, w := range world.wells {
+		ax, ay := w.Pull(shipX, shipY)
+		p.vx += ax
+		p.vy += ay
+		if utils.WrapDistance(shipX, shipY, w.x, w.y, WorldWidth) < w.core {
+			p.Damage(wellCoreDamage)
+		}
+		for _, b := range p.bullets {
+			if b.active {
+				ax, ay := w.Pull(b.x, b.y)
+				b.vx += ax
+				b.vy += ay
+			}
+		}
+	}
+
+	for _, a := range world.asteroids {
+		if !a.active {
+			continue
+		}
+		a.x = utils.Wrap(a.x+a.vx, WorldWidth)
+		a.y += a.vy
+		a.rotation += a.spin
+
+		// Bounce off the top of the world and the ground
+		if a.y-a.radius < 0 && a.vy < 0 {
+			a.vy = -a.vy
+		}
+		if a.y+a.radius > world.GroundY(a.x) && a.vy > 0 {
+			a.vy = -a.vy
+		}
+
+		// Smash into the ship
+		dx, dy := utils.WrapDirection(shipX, shipY, a.x, a.y, WorldWidth)
+		if math.Abs(dx) < shipWidth/2+a.radius*0.8 && math.Abs(dy) < shipHeight/2+a.radius*0.8 {
+			p.Damage(a.radius * asteroidDamage)
+			world.breakAsteroid(a)
+		}
+	}
+
+	// Shoot them up
+	for _, b := range p.bullets {
+		if !b.active {
+			continue
+		}
+		for _, a := range world.asteroids {
+			if a.active && utils.WrapDistance(b.x, b.y, a.x, a.y, WorldWidth) < a.radius {
+				b.active = false
+				world.addScore(asteroidScore)
+				world.breakAsteroid(a)
+				break
+			}
+		}
+	}
+
+	// Forget the dust
+	kept := world.asteroids[:0]
+	for _, a := range world.asteroids {
+		if a.active {
+			kept = append(kept, a)
+		}
+	}
+	world.asteroids = kept
+}
+
+// breakAsteroid splits an asteroid in two smaller ones, or crumbles it
+// away if it is already small
+func (world *World) breakAsteroid(a *Asteroid) {
+	a.active = false
+	world.viewport.AddTrauma(asteroidTrauma)
+	world.particles.Burst("debris", a.x, a.y, 0)
+	radius := a.radius * asteroidSplit
+	if radius < asteroidMinRadius {
+		return
+	}
+	for range 2 {
+		child := NewAsteroid(a.x, a.y, radius, world.rng)
+		child.vx += a.vx
+		child.vy += a.vy
+		world.asteroids = append(world.asteroids, child)
+	}
+}
+
+// drawHazards draws the gravity wells and every asteroid in one batch
+func (world *World) drawHazards(screen *ebiten.Image) {
+	v, pal := world.viewport, world.library.Palette()
+	for _, w := range world.wells {
+		world.drawWell(screen, w)
+	}
+
+	world.hazardVertices = world.hazardVertices[:0]
+	world.hazardIndices = world.hazardIndices[:0]
+	var outline vector.Path
+	for _, a := range world.asteroids {
+		cx, cy := v.WorldToScreen(v.Lerp(a.prevX, a.prevY, a.x, a.y))
+		rotation := a.prevRotation + (a.rotation-a.prevRotation)*v.alpha
+		if cx < -a.radius || cx > v.width+a.radius || cy < -a.radius || cy > v.height+a.radius {
+			continue
+		}
+
+		// Fan of triangles around the center
+		base := uint16(len(world.hazardVertices))
+		world.hazardVertices = append(world.hazardVertices, solidVertex(float32(cx), float32(cy), pal.Asteroid.RGBA))
+		for i, s := range a.shape {
+			angle := rotation + float64(i)*2*math.Pi/asteroidPoints
+			x := float32(cx + math.Cos(angle)*a.radius*s)
+			y := float32(cy + math.Sin(angle)*a.radius*s)
+			world.hazardVertices = append(world.hazardVertices, solidVertex(x, y, pal.Asteroid.RGBA))
+			next := uint16(i+1)%asteroidPoints + 1
+			world.hazardIndices = append(world.hazardIndices, base, base+uint16(i)+1, base+next)
+			if i == 0 {
+				outline.MoveTo(x, y)
+			} else {
+				outline.LineTo(x, y)
+			}
+		}
+		outline.Close()
+	}
+	screen.DrawTriangles(world.hazardVertices, world.hazardIndices, whitePixel, nil)
+
+	world.hazardVertices, world.hazardIndices = outline.AppendVerticesAndIndicesForStroke(world.hazardVertices[:0], world.hazardIndices[:0], &vector.StrokeOptions{Width: 2})
+	colorVertices(world.hazardVertices, pal.AsteroidOutline.RGBA)
+	screen.DrawTriangles(world.hazardVertices, world.hazardIndices, whitePixel, &ebiten.DrawTrianglesOptions{AntiAlias: true})
+}
+
+// drawWell draws a dark core with a swirl of glowing matter spiralling into it
+func (world *World) drawWell(screen *ebiten.Image, w *GravityWell) {
+	v, glow := world.viewport, world.library.Palette().Well.RGBA
+	cx, cy := v.WorldToScreen(w.x, w.y)
+	if cx < -w.radius || cx > v.width+w.radius || cy < -w.radius || cy > v.height+w.radius {
+		return
+	}
+
+	faint := glow
+	faint.A = 40
+	vector.StrokeCircle(screen, float32(cx), float32(cy), float32(w.radius), 1, premultiply(faint), true)
+
+	// Matter orbits faster the closer it gets to the core
+	t := float64(world.ticks)
+	for i := range wellDots {
+		orbit := w.core + float64(i)/wellDots*(w.radius*0.6)
+		angle := float64(i)*2.4 + t*0.02*w.core/orbit*3
+		x := cx + math.Cos(angle)*orbit
+		y := cy + math.Sin(angle)*orbit*0.8
+		dot := glow
+		dot.A = uint8(255 * (1 - float64(i)/wellDots))
+		vector.DrawFilledCircle(screen, float32(x), float32(y), 2, premultiply(dot), false)
+	}
+
+	vector.DrawFilledCircle(screen, float32(cx), float32(cy), float32(w.core), color.Black, true)
+	vector.StrokeCircle(screen, float32(cx), float32(cy), float32(w.core), 2, glow, true)
+}
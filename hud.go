@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+
+	"github.com/fabiomsouto/dfndr/internal/theme"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	hudMargin     = 12
+	hudSmallSize  = 10
+	hudLargeSize  = 16
+	hudIconScale  = 0.25 // Size of the lives icons relative to the ship sprite
+	hudBarWidth   = 160
+	hudBarHeight  = 8
+	hudFlashTicks = 30 // Blink period of the low shield warning
+)
+
+// HUD shows the score and the state of the ship around the scanner. It is
+// laid out from the size of the screen it is drawn on.
+type HUD struct {
+	small, large *text.GoTextFace
+	ship         *ebiten.Image // Lives icon
+	palette      *Palette
+	ticks        int
+}
+
+// loadFont reads a TrueType font from a theme pack
+func loadFont(file theme.File) (*text.GoTextFaceSource, error) {
+	data, err := file.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font %s: %w", file.Path, err)
+	}
+	source, err := text.NewGoTextFaceSource(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font %s: %w", file.Path, err)
+	}
+	return source, nil
+}
+
+func NewHUD(library *AssetManager) *HUD {
+	return &HUD{
+		small:   &text.GoTextFace{Source: library.Font(), Size: hudSmallSize},
+		large:   &text.GoTextFace{Source: library.Font(), Size: hudLargeSize},
+		ship:    library.Sprite(SpriteShip).frames[0],
+		palette: library.Palette(),
+	}
+}
+
+func (h *HUD) Update() {
+	h.ticks++
+}
+
+func (h *HUD) Draw(screen *ebiten.Image, world *World) {
+	bounds := screen.Bounds()
+	width, height := float64(bounds.Dx()), float64(bounds.Dy())
+	p, pal := world.player, h.palette
+
+	// Score and multiplier, top left
+	h.print(screen, fmt.Sprintf("%07d", world.score), h.large, hudMargin, hudMargin, text.AlignStart, pal.Text.RGBA)
+	if world.multiplier > 1 {
+		h.print(screen, fmt.Sprintf("x%d", world.multiplier), h.small, hudMargin, hudMargin+hudLargeSize+6, text.AlignStart, pal.Multiplier.RGBA)
+	}
+
+	// Lives and smart bombs, top right
+	right := width - hudMargin
+	iconWidth := float64(h.ship.Bounds().Dx()) * hudIconScale
+	for i := range p.lives {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(hudIconScale, hudIconScale)
+		op.GeoM.Translate(right-float64(i+1)*(iconWidth+4), hudMargin)
+		op.Filter = ebiten.FilterLinear
+		screen.DrawImage(h.ship, op)
+	}
+	bombs := ""
+	for range p.bombs {
+		bombs += "*"
+	}
+	h.print(screen, "BOMBS "+bombs, h.small, right, hudMargin+hudLargeSize+6, text.AlignEnd, pal.Text.RGBA)
+
+	// Level and wave, bottom left
+	bottom := height - hudMargin - hudSmallSize
+	h.print(screen, fmt.Sprintf("LEVEL %d  WAVE %d", world.level, world.waves.wave), h.small, hudMargin, bottom-hudSmallSize-6, text.AlignStart, pal.Text.RGBA)
+	h.print(screen, world.sector.biome.name, h.small, hudMargin, bottom, text.AlignStart, pal.Dim.RGBA)
+
+	// Shield, bottom right, blinking once it runs low
+	fill := p.shield / maxShield
+	clr := pal.Shield.RGBA
+	if fill < 0.25 {
+		clr = pal.ShieldLow.RGBA
+		if h.ticks%hudFlashTicks < hudFlashTicks/2 {
+			clr = pal.BarBack.RGBA
+		}
+	}
+	barX := float32(right - hudBarWidth)
+	barY := float32(bottom)
+	vector.DrawFilledRect(screen, barX, barY, hudBarWidth, hudBarHeight, pal.BarBack.RGBA, false)
+	vector.DrawFilledRect(screen, barX, barY, float32(hudBarWidth*max(0, fill)), hudBarHeight, clr, false)
+	h.print(screen, "SHIELD", h.small, float64(barX)-8, bottom, text.AlignEnd, pal.Text.RGBA)
+}
+
+func (h *HUD) print(screen *ebiten.Image, s string, face *text.GoTextFace, x, y float64, align text.Align, clr color.Color) {
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(x, y)
+	op.PrimaryAlign = align
+	op.ColorScale.ScaleWithColor(clr)
+	text.Draw(screen, s, face, op)
+}
@@ -0,0 +1,135 @@
+// Package anim plays frame-based sprite animations. It only tracks which
+// frame of a sprite sheet to show; drawing is left to the caller.
+package anim
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Mode controls what a clip does after its last frame
+type Mode string
+
+const (
+	Loop Mode = "loop" // Start over from the first frame
+	Once Mode = "once" // Hold the last frame and report Done
+)
+
+// Clip is a named sequence of sprite sheet frames
+type Clip struct {
+	Frames    []int `json:"frames"`    // Frame indices into the sheet, left to right
+	Durations []int `json:"durations"` // How long each frame is shown, in ticks
+	Mode      Mode  `json:"mode"`
+}
+
+// Sheet describes how a sprite sheet image is cut into frames and clips
+type Sheet struct {
+	Image       string           `json:"sheet"` // Image file name within the assets
+	FrameWidth  int              `json:"frameWidth"`
+	FrameHeight int              `json:"frameHeight"`
+	Clips       map[string]*Clip `json:"clips"`
+}
+
+// Parse decodes and validates a sheet definition
+func Parse(data []byte) (*Sheet, error) {
+	var s Sheet
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if s.FrameWidth <= 0 || s.FrameHeight <= 0 {
+		return nil, fmt.Errorf("sheet %q has invalid frame size %dx%d", s.Image, s.FrameWidth, s.FrameHeight)
+	}
+	for name, c := range s.Clips {
+		if len(c.Frames) == 0 {
+			return nil, fmt.Errorf("clip %q has no frames", name)
+		}
+		if len(c.Durations) != len(c.Frames) {
+			return nil, fmt.Errorf("clip %q has %d frames but %d durations", name, len(c.Frames), len(c.Durations))
+		}
+		for _, d := range c.Durations {
+			if d <= 0 {
+				return nil, fmt.Errorf("clip %q has a non-positive frame duration", name)
+			}
+		}
+		if c.Mode != Loop && c.Mode != Once {
+			return nil, fmt.Errorf("clip %q has unknown mode %q", name, c.Mode)
+		}
+	}
+	return &s, nil
+}
+
+// Clip returns the named clip, or nil if the sheet doesn't have it
+func (s *Sheet) Clip(name string) *Clip {
+	return s.Clips[name]
+}
+
+// Animator plays one clip at a time
+type Animator struct {
+	clip    *Clip
+	name    string
+	index   int // Position within the clip's frames
+	elapsed int // Ticks spent on the current frame
+	done    bool
+}
+
+// Play switches to the named clip of sheet, starting from its first frame.
+// Playing the clip that is already running leaves it undisturbed.
+func (a *Animator) Play(sheet *Sheet, name string) {
+	if a.name == name && a.clip != nil {
+		return
+	}
+	a.Restart(sheet, name)
+}
+
+// Restart starts the named clip from its first frame, even if it is
+// already playing
+func (a *Animator) Restart(sheet *Sheet, name string) {
+	clip := sheet.Clip(name)
+	if clip == nil {
+		return
+	}
+	a.clip = clip
+	a.name = name
+	a.index = 0
+	a.elapsed = 0
+	a.done = false
+}
+
+// Update advances the animation by one tick
+func (a *Animator) Update() {
+	if a.clip == nil || a.done {
+		return
+	}
+	a.elapsed++
+	if a.elapsed < a.clip.Durations[a.index] {
+		return
+	}
+	a.elapsed = 0
+	if a.index < len(a.clip.Frames)-1 {
+		a.index++
+		return
+	}
+	if a.clip.Mode == Loop {
+		a.index = 0
+	} else {
+		a.done = true
+	}
+}
+
+// Frame returns the sheet frame to draw
+func (a *Animator) Frame() int {
+	if a.clip == nil {
+		return 0
+	}
+	return a.clip.Frames[a.index]
+}
+
+// Playing returns the name of the current clip
+func (a *Animator) Playing() string {
+	return a.name
+}
+
+// Done reports whether a one-shot clip has shown its last frame
+func (a *Animator) Done() bool {
+	return a.done
+}
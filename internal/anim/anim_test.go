@@ -0,0 +1,71 @@
+package anim
+
+import (
+	"testing"
+)
+
+const testSheet = `{
+  "sheet": "test.png",
+  "frameWidth": 10,
+  "frameHeight": 10,
+  "clips": {
+    "idle": {"frames": [0, 1], "durations": [2, 1], "mode": "loop"},
+    "hit": {"frames": [2, 3], "durations": [1, 1], "mode": "once"}
+  }
+}`
+
+func TestLoopingClip(t *testing.T) {
+	s, err := Parse([]byte(testSheet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a Animator
+	a.Play(s, "idle")
+
+	want := []int{0, 0, 1, 0, 0, 1}
+	for i, w := range want {
+		if got := a.Frame(); got != w {
+			t.Fatalf("tick %d: frame = %d, want %d", i, got, w)
+		}
+		a.Update()
+	}
+	if a.Done() {
+		t.Error("looping clip reported done")
+	}
+}
+
+func TestOneShotClip(t *testing.T) {
+	s, err := Parse([]byte(testSheet))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a Animator
+	a.Play(s, "hit")
+	a.Update()
+	if a.Frame() != 3 || a.Done() {
+		t.Fatalf("after 1 tick: frame = %d, done = %v", a.Frame(), a.Done())
+	}
+	a.Update()
+	a.Update()
+	if a.Frame() != 3 || !a.Done() {
+		t.Errorf("after finishing: frame = %d, done = %v, want 3, true", a.Frame(), a.Done())
+	}
+
+	// Replaying the same clip doesn't restart it, Restart does
+	a.Play(s, "hit")
+	if !a.Done() {
+		t.Error("Play restarted a clip that was already current")
+	}
+	a.Restart(s, "hit")
+	if a.Done() || a.Frame() != 2 {
+		t.Error("Restart did not rewind the clip")
+	}
+}
+
+func TestParseRejectsMismatchedDurations(t *testing.T) {
+	bad := `{"sheet": "x.png", "frameWidth": 1, "frameHeight": 1,
+	  "clips": {"idle": {"frames": [0, 1], "durations": [1], "mode": "loop"}}}`
+	if _, err := Parse([]byte(bad)); err == nil {
+		t.Error("expected an error for mismatched durations")
+	}
+}
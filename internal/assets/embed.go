@@ -2,5 +2,5 @@ package assets
 
 import "embed"
 
-//go:embed *.png
+//go:embed theme.json *.png formations/*.json animations/*.json backgrounds/*.json particles/*.json shaders/*.kage fonts/*.ttf palettes/*.json themes/*/*.json
 var Assets embed.FS
@@ -0,0 +1,40 @@
+// Package atlas packs rectangles into a single texture. It only works out
+// where each rectangle goes; copying the pixels is left to the caller.
+package atlas
+
+import (
+	"image"
+	"sort"
+)
+
+// Pack places rectangles of the given sizes on shelves no wider than
+// width, leaving padding pixels between them. It returns where each
+// rectangle went, in the order they were given, and the size of the
+// texture they need. Rectangles wider than width get a shelf to themselves.
+func Pack(sizes []image.Point, width, padding int) ([]image.Rectangle, image.Point) {
+	// Tallest first keeps the shelves tight
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return sizes[order[a]].Y > sizes[order[b]].Y
+	})
+
+	rects := make([]image.Rectangle, len(sizes))
+	var x, y, shelf, used int
+	for _, i := range order {
+		s := sizes[i]
+		if x > 0 && x+s.X > width {
+			// Start a new shelf
+			x = 0
+			y += shelf + padding
+			shelf = 0
+		}
+		rects[i] = image.Rect(x, y, x+s.X, y+s.Y)
+		x += s.X + padding
+		shelf = max(shelf, s.Y)
+		used = max(used, x-padding)
+	}
+	return rects, image.Pt(used, y+shelf)
+}
@@ -0,0 +1,46 @@
+package atlas
+
+import (
+	"image"
+	"testing"
+)
+
+func TestPackWithoutOverlap(t *testing.T) {
+	sizes := []image.Point{{50, 40}, {100, 49}, {30, 30}, {64, 64}, {50, 40}, {200, 10}}
+	rects, size := Pack(sizes, 160, 1)
+
+	bounds := image.Rectangle{Max: size}
+	for i, r := range rects {
+		if r.Size() != sizes[i] {
+			t.Errorf("rect %d is %v, want %v", i, r.Size(), sizes[i])
+		}
+		if !r.In(bounds) {
+			t.Errorf("rect %d at %v is outside the %v texture", i, r, size)
+		}
+		for j := range i {
+			if r.Overlaps(rects[j]) {
+				t.Errorf("rects %d and %d overlap: %v, %v", i, j, r, rects[j])
+			}
+		}
+	}
+}
+
+func TestPackPadding(t *testing.T) {
+	rects, size := Pack([]image.Point{{10, 10}, {10, 10}}, 100, 2)
+	if rects[1].Min.X-rects[0].Max.X != 2 {
+		t.Errorf("rects are %v apart, want 2", rects[1].Min.X-rects[0].Max.X)
+	}
+	if size != image.Pt(22, 10) {
+		t.Errorf("texture size = %v, want 22x10", size)
+	}
+}
+
+func TestPackWrapsShelves(t *testing.T) {
+	rects, size := Pack([]image.Point{{60, 20}, {60, 10}}, 100, 0)
+	if rects[1].Min != image.Pt(0, 20) {
+		t.Errorf("second rect at %v, want it on a new shelf at 0,20", rects[1].Min)
+	}
+	if size != image.Pt(60, 30) {
+		t.Errorf("texture size = %v, want 60x30", size)
+	}
+}
@@ -0,0 +1,121 @@
+// Package fsm is a small finite-state machine for game entities.
+//
+// A Definition holds the states, which are shared between every entity that
+// uses it. Each entity owns a Machine that tracks its current state.
+package fsm
+
+import "fmt"
+
+// State is one state of a machine. All callbacks are optional.
+type State[T any] struct {
+	Name string
+	// Enter runs when the machine switches into this state
+	Enter func(owner T)
+	// Update runs once per tick and returns the name of the state to switch
+	// to, or an empty string to stay
+	Update func(owner T, ticks int) string
+	// Exit runs when the machine leaves this state
+	Exit func(owner T)
+	// On maps event names to the state the machine switches to when the
+	// event is received in this state
+	On map[string]string
+}
+
+// Definition is a set of states
+type Definition[T any] struct {
+	states map[string]*State[T]
+}
+
+// NewDefinition builds a definition from its states. It panics if a state
+// name is repeated or a transition points at an unknown state, since both
+// are programming errors.
+func NewDefinition[T any](states ...*State[T]) *Definition[T] {
+	d := &Definition[T]{states: make(map[string]*State[T], len(states))}
+	for _, s := range states {
+		if _, dup := d.states[s.Name]; dup {
+			panic(fmt.Sprintf("fsm: duplicate state %q", s.Name))
+		}
+		d.states[s.Name] = s
+	}
+	for _, s := range states {
+		for event, target := range s.On {
+			if _, ok := d.states[target]; !ok {
+				panic(fmt.Sprintf("fsm: state %q sends event %q to unknown state %q", s.Name, event, target))
+			}
+		}
+	}
+	return d
+}
+
+// Machine tracks the current state of one entity
+type Machine[T any] struct {
+	def     *Definition[T]
+	current *State[T]
+	ticks   int // Ticks spent in the current state
+}
+
+// NewMachine returns a machine for def, starting in the named state. The
+// initial state's Enter callback runs immediately.
+func NewMachine[T any](def *Definition[T], owner T, initial string) *Machine[T] {
+	m := &Machine[T]{def: def}
+	m.Set(owner, initial)
+	return m
+}
+
+// Set switches to the named state, running the Exit and Enter callbacks.
+// Unknown state names are ignored.
+func (m *Machine[T]) Set(owner T, name string) {
+	next, ok := m.def.states[name]
+	if !ok {
+		return
+	}
+	if m.current != nil && m.current.Exit != nil {
+		m.current.Exit(owner)
+	}
+	m.current = next
+	m.ticks = 0
+	if next.Enter != nil {
+		next.Enter(owner)
+	}
+}
+
+// Update runs the current state for one tick and performs the transition it asks for
+func (m *Machine[T]) Update(owner T) {
+	if m.current == nil {
+		return
+	}
+	m.ticks++
+	if m.current.Update == nil {
+		return
+	}
+	if next := m.current.Update(owner, m.ticks); next != "" && next != m.current.Name {
+		m.Set(owner, next)
+	}
+}
+
+// Send delivers an event, switching state if the current state reacts to it.
+// It reports whether a transition happened.
+func (m *Machine[T]) Send(owner T, event string) bool {
+	if m.current == nil {
+		return false
+	}
+	target, ok := m.current.On[event]
+	if !ok {
+		return false
+	}
+	m.Set(owner, target)
+	return true
+}
+
+// Current returns the name of the current state
+func (m *Machine[T]) Current() string {
+	if m.current == nil {
+		return ""
+	}
+	return m.current.Name
+}
+
+// Ticks returns how long the machine has been in its current state
+func (m *Machine[T]) Ticks() int {
+	return m.ticks
+}
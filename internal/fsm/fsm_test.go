@@ -0,0 +1,65 @@
+package fsm
+
+import "testing"
+
+type light struct {
+	log []string
+}
+
+func TestTransitions(t *testing.T) {
+	def := NewDefinition(
+		&State[*light]{
+			Name:  "green",
+			Enter: func(l *light) { l.log = append(l.log, "enter green") },
+			Exit:  func(l *light) { l.log = append(l.log, "exit green") },
+			Update: func(l *light, ticks int) string {
+				if ticks >= 2 {
+					return "red"
+				}
+				return ""
+			},
+		},
+		&State[*light]{
+			Name: "red",
+			On:   map[string]string{"reset": "green"},
+		},
+	)
+
+	l := &light{}
+	m := NewMachine(def, l, "green")
+	m.Update(l)
+	if m.Current() != "green" || m.Ticks() != 1 {
+		t.Fatalf("after 1 tick: %s/%d", m.Current(), m.Ticks())
+	}
+	m.Update(l)
+	if m.Current() != "red" || m.Ticks() != 0 {
+		t.Fatalf("after 2 ticks: %s/%d, want red/0", m.Current(), m.Ticks())
+	}
+
+	if m.Send(l, "unknown") {
+		t.Error("unknown event caused a transition")
+	}
+	if !m.Send(l, "reset") || m.Current() != "green" {
+		t.Errorf("reset event left machine in %s", m.Current())
+	}
+
+	want := []string{"enter green", "exit green", "enter green"}
+	if len(l.log) != len(want) {
+		t.Fatalf("callbacks = %v, want %v", l.log, want)
+	}
+	for i := range want {
+		if l.log[i] != want[i] {
+			t.Errorf("callbacks = %v, want %v", l.log, want)
+			break
+		}
+	}
+}
+
+func TestNewDefinitionRejectsUnknownTarget(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an unknown transition target")
+		}
+	}()
+	NewDefinition(&State[int]{Name: "a", On: map[string]string{"go": "b"}})
+}
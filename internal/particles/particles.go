@@ -0,0 +1,233 @@
+// Package particles simulates short-lived particles in a fixed-capacity pool.
+// Emitters describe how particles are born; drawing is left to the caller.
+package particles
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Shape controls where and in which direction an emitter spawns particles
+type Shape string
+
+const (
+	Point Shape = "point" // From the origin, in every direction
+	Cone  Shape = "cone"  // From the origin, within Spread of the heading
+	Ring  Shape = "ring"  // From a circle of Radius around the origin, outwards
+)
+
+// Stop is a point on a color gradient
+type Stop struct {
+	At    float64  `json:"at"` // Fraction of the particle's life, 0-1
+	Color [4]uint8 `json:"color"`
+}
+
+// Gradient is a particle's color over its life, as stops sorted by At
+type Gradient []Stop
+
+// At returns the gradient's color at t, a fraction of the particle's life.
+// The color is straight alpha.
+func (g Gradient) At(t float64) color.RGBA {
+	switch {
+	case len(g) == 0:
+		return color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	case t <= g[0].At:
+		return stopColor(g[0].Color)
+	case t >= g[len(g)-1].At:
+		return stopColor(g[len(g)-1].Color)
+	}
+	i := sort.Search(len(g), func(i int) bool { return g[i].At > t })
+	a, b := g[i-1], g[i]
+	f := (t - a.At) / (b.At - a.At)
+	var c [4]uint8
+	for k := range c {
+		c[k] = uint8(float64(a.Color[k]) + (float64(b.Color[k])-float64(a.Color[k]))*f + 0.5)
+	}
+	return stopColor(c)
+}
+
+func stopColor(c [4]uint8) color.RGBA {
+	return color.RGBA{R: c[0], G: c[1], B: c[2], A: c[3]}
+}
+
+// Emitter is a particle effect definition. Bursts spawn Count particles at
+// once; continuous emitters spawn Rate particles per tick through a Source.
+type Emitter struct {
+	Shape   Shape      `json:"shape"`
+	Count   int        `json:"count"`   // Particles per burst
+	Rate    float64    `json:"rate"`    // Particles per tick when emitting continuously
+	Spread  float64    `json:"spread"`  // Half angle of a cone, in radians
+	Radius  float64    `json:"radius"`  // Radius of a ring
+	Speed   [2]float64 `json:"speed"`   // Range of initial speeds
+	Life    [2]float64 `json:"life"`    // Range of lifetimes, in ticks
+	Size    [2]float64 `json:"size"`    // Range of initial sizes
+	Spin    float64    `json:"spin"`    // Largest rotation speed, either way, in radians per tick
+	Drag    float64    `json:"drag"`    // Fraction of velocity lost each tick
+	Gravity float64    `json:"gravity"` // Downward acceleration, per tick
+	Shrink  float64    `json:"shrink"`  // Fraction of size lost over the particle's life
+	Colors  Gradient   `json:"colors"`
+}
+
+// Parse decodes and validates an emitter definition
+func Parse(data []byte) (*Emitter, error) {
+	var e Emitter
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	switch e.Shape {
+	case Point, Cone, Ring:
+	default:
+		return nil, fmt.Errorf("unknown emitter shape %q", e.Shape)
+	}
+	if e.Count <= 0 && e.Rate <= 0 {
+		return nil, fmt.Errorf("emitter has neither a burst count nor a rate")
+	}
+	if e.Life[0] <= 0 || e.Life[1] < e.Life[0] {
+		return nil, fmt.Errorf("emitter has invalid life range %v", e.Life)
+	}
+	for i, s := range e.Colors {
+		if i > 0 && s.At < e.Colors[i-1].At {
+			return nil, fmt.Errorf("emitter color stops are out of order")
+		}
+	}
+	return &e, nil
+}
+
+// Particle is a single live particle
+type Particle struct {
+	X, Y     float64
+	PrevX    float64 // Position at the previous tick, see Pool.Snapshot
+	PrevY    float64
+	VX, VY   float64
+	Size     float64
+	Rotation float64
+	Spin     float64
+	Age      float64 // Ticks lived
+	Life     float64 // Ticks to live
+	emitter  *Emitter
+}
+
+// Progress returns how far through its life the particle is, 0-1
+func (p *Particle) Progress() float64 {
+	return p.Age / p.Life
+}
+
+// Color returns the particle's current color
+func (p *Particle) Color() color.RGBA {
+	return p.emitter.Colors.At(p.Progress())
+}
+
+// CurrentSize returns the particle's size after shrinking with age
+func (p *Particle) CurrentSize() float64 {
+	return p.Size * (1 - p.emitter.Shrink*p.Progress())
+}
+
+// Pool holds every live particle. It never grows past its capacity: once
+// full, new particles are dropped until old ones die.
+type Pool struct {
+	particles []Particle
+	rng       *rand.Rand
+}
+
+func NewPool(capacity int, seed int64) *Pool {
+	return &Pool{
+		particles: make([]Particle, 0, capacity),
+		rng:       rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Particles returns the live particles. The slice is only valid until the
+// next call to Update or Burst.
+func (p *Pool) Particles() []Particle {
+	return p.particles
+}
+
+// Burst spawns a burst of the emitter's particles at x, y. heading is the
+// direction of a cone, in radians.
+func (p *Pool) Burst(e *Emitter, x, y, heading float64) {
+	p.spawn(e, e.Count, x, y, heading)
+}
+
+func (p *Pool) spawn(e *Emitter, count int, x, y, heading float64) {
+	for range count {
+		if len(p.particles) == cap(p.particles) {
+			return
+		}
+		angle := p.rng.Float64() * 2 * math.Pi
+		if e.Shape == Cone {
+			angle = heading + (p.rng.Float64()*2-1)*e.Spread
+		}
+		sin, cos := math.Sincos(angle)
+		px, py := x, y
+		if e.Shape == Ring {
+			px += cos * e.Radius
+			py += sin * e.Radius
+		}
+		speed := between(p.rng, e.Speed)
+		p.particles = append(p.particles, Particle{
+			X:        px,
+			Y:        py,
+			PrevX:    px,
+			PrevY:    py,
+			VX:       cos * speed,
+			VY:       sin * speed,
+			Size:     between(p.rng, e.Size),
+			Rotation: p.rng.Float64() * math.Pi,
+			Spin:     (p.rng.Float64()*2 - 1) * e.Spin,
+			Life:     between(p.rng, e.Life),
+			emitter:  e,
+		})
+	}
+}
+
+// Snapshot remembers where every particle is, so drawing can interpolate
+// between this tick and the next
+func (p *Pool) Snapshot() {
+	for i := range p.particles {
+		p.particles[i].PrevX, p.particles[i].PrevY = p.particles[i].X, p.particles[i].Y
+	}
+}
+
+// Update ages and moves every particle by one tick and drops the dead ones
+func (p *Pool) Update() {
+	for i := 0; i < len(p.particles); {
+		pt := &p.particles[i]
+		pt.Age++
+		if pt.Age >= pt.Life {
+			// Swap the last particle into the hole, order doesn't matter
+			last := len(p.particles) - 1
+			p.particles[i] = p.particles[last]
+			p.particles = p.particles[:last]
+			continue
+		}
+		e := pt.emitter
+		pt.VX *= 1 - e.Drag
+		pt.VY = pt.VY*(1-e.Drag) + e.Gravity
+		pt.X += pt.VX
+		pt.Y += pt.VY
+		pt.Rotation += pt.Spin
+		i++
+	}
+}
+
+// Source emits particles continuously, at the emitter's rate
+type Source struct {
+	Emitter *Emitter
+	carry   float64 // Fraction of a particle owed from previous ticks
+}
+
+// Emit spawns this tick's share of particles at x, y
+func (s *Source) Emit(p *Pool, x, y, heading float64) {
+	s.carry += s.Emitter.Rate
+	n := int(s.carry)
+	s.carry -= float64(n)
+	p.spawn(s.Emitter, n, x, y, heading)
+}
+
+func between(rng *rand.Rand, r [2]float64) float64 {
+	return r[0] + rng.Float64()*(r[1]-r[0])
+}
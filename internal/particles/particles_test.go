@@ -0,0 +1,131 @@
+package particles
+
+import (
+	"math"
+	"testing"
+)
+
+const testEmitter = `{
+  "shape": "ring",
+  "count": 8,
+  "radius": 10,
+  "speed": [1, 1],
+  "life": [3, 3],
+  "size": [4, 4],
+  "shrink": 0.5,
+  "colors": [
+    {"at": 0, "color": [255, 0, 0, 255]},
+    {"at": 1, "color": [0, 0, 255, 0]}
+  ]
+}`
+
+func mustParse(t *testing.T, data string) *Emitter {
+	t.Helper()
+	e, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestGradient(t *testing.T) {
+	g := Gradient{
+		{At: 0, Color: [4]uint8{0, 0, 0, 255}},
+		{At: 0.5, Color: [4]uint8{200, 100, 0, 255}},
+		{At: 1, Color: [4]uint8{200, 100, 0, 0}},
+	}
+	if c := g.At(0.25); c.R != 100 || c.G != 50 || c.A != 255 {
+		t.Errorf("At(0.25) = %v", c)
+	}
+	if c := g.At(0.75); c.R != 200 || c.A != 128 {
+		t.Errorf("At(0.75) = %v", c)
+	}
+	if c := g.At(2); c.A != 0 {
+		t.Errorf("At(2) = %v, want the last stop", c)
+	}
+}
+
+func TestRingBurst(t *testing.T) {
+	e := mustParse(t, testEmitter)
+	p := NewPool(100, 1)
+	p.Burst(e, 50, 50, 0)
+	if n := len(p.Particles()); n != 8 {
+		t.Fatalf("burst spawned %d particles, want 8", n)
+	}
+	for _, pt := range p.Particles() {
+		if d := math.Hypot(pt.X-50, pt.Y-50); math.Abs(d-10) > 1e-9 {
+			t.Errorf("particle spawned %v from the center, want 10", d)
+		}
+		// Ring particles fly outwards
+		if dot := (pt.X-50)*pt.VX + (pt.Y-50)*pt.VY; dot <= 0 {
+			t.Errorf("particle at %v,%v heads inwards", pt.X, pt.Y)
+		}
+	}
+}
+
+func TestPoolCapacity(t *testing.T) {
+	e := mustParse(t, testEmitter)
+	p := NewPool(10, 1)
+	p.Burst(e, 0, 0, 0)
+	p.Burst(e, 0, 0, 0)
+	if n := len(p.Particles()); n != 10 {
+		t.Fatalf("pool holds %d particles, want its capacity of 10", n)
+	}
+}
+
+func TestParticlesAgeAndDie(t *testing.T) {
+	e := mustParse(t, testEmitter)
+	p := NewPool(100, 1)
+	p.Burst(e, 0, 0, 0)
+
+	p.Update()
+	pt := p.Particles()[0]
+	if got := pt.CurrentSize(); math.Abs(got-4*(1-0.5/3)) > 1e-9 {
+		t.Errorf("size after one tick = %v", got)
+	}
+	p.Update()
+	p.Update()
+	if n := len(p.Particles()); n != 0 {
+		t.Errorf("%d particles outlived their life", n)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	e := mustParse(t, `{"shape": "cone", "count": 1, "spread": 0, "speed": [2, 2], "life": [100, 100]}`)
+	p := NewPool(10, 1)
+	p.Burst(e, 5, 5, 0)
+	p.Snapshot()
+	p.Update()
+	pt := p.Particles()[0]
+	if pt.PrevX != 5 || pt.PrevY != 5 || pt.X == pt.PrevX {
+		t.Errorf("particle moved from %v,%v to %v,%v, want from the snapshot at 5,5", pt.PrevX, pt.PrevY, pt.X, pt.Y)
+	}
+}
+
+func TestSourceRate(t *testing.T) {
+	e := mustParse(t, `{"shape": "cone", "rate": 0.5, "spread": 0, "speed": [2, 2], "life": [100, 100]}`)
+	p := NewPool(100, 1)
+	s := Source{Emitter: e}
+	for range 10 {
+		s.Emit(p, 0, 0, math.Pi/2)
+	}
+	if n := len(p.Particles()); n != 5 {
+		t.Fatalf("source emitted %d particles in 10 ticks, want 5", n)
+	}
+	if pt := p.Particles()[0]; math.Abs(pt.VX) > 1e-9 || math.Abs(pt.VY-2) > 1e-9 {
+		t.Errorf("cone particle velocity = %v,%v, want straight down", pt.VX, pt.VY)
+	}
+}
+
+func TestParseRejectsBadEmitters(t *testing.T) {
+	for _, data := range []string{
+		`{"shape": "star", "count": 1, "life": [1, 1]}`,
+		`{"shape": "point", "life": [1, 1]}`,
+		`{"shape": "point", "count": 1, "life": [0, 1]}`,
+		`{"shape": "point", "count": 1, "life": [1, 1], "colors": [{"at": 1}, {"at": 0}]}`,
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse(%s) succeeded", data)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Package path builds splines from control points and flattens them into
+// curves that can be followed at a constant speed.
+package path
+
+import (
+	"fmt"
+	"math"
+)
+
+// Number of line segments each spline segment is flattened into
+const samplesPerSegment = 24
+
+// Point is a 2D point on a path
+type Point struct {
+	X, Y float64
+}
+
+// Curve is a spline flattened into a polyline, indexed by arc length so
+// followers can move along it at a constant speed
+type Curve struct {
+	points []Point
+	dist   []float64 // Cumulative arc length at each point
+}
+
+// New builds a curve of the named kind, either "bezier" or "catmull-rom"
+func New(kind string, ctrl []Point) (*Curve, error) {
+	switch kind {
+	case "bezier":
+		return Bezier(ctrl)
+	case "catmull-rom":
+		return CatmullRom(ctrl)
+	default:
+		return nil, fmt.Errorf("unknown spline kind %q", kind)
+	}
+}
+
+// Bezier builds a chain of cubic Bezier segments. Consecutive segments
+// share an end point, so ctrl must hold 3n+1 points for n segments.
+func Bezier(ctrl []Point) (*Curve, error) {
+	if len(ctrl) < 4 || (len(ctrl)-1)%3 != 0 {
+		return nil, fmt.Errorf("bezier path needs 3n+1 control points, got %d", len(ctrl))
+	}
+	pts := []Point{ctrl[0]}
+	for i := 0; i+3 < len(ctrl); i += 3 {
+		p0, p1, p2, p3 := ctrl[i], ctrl[i+1], ctrl[i+2], ctrl[i+3]
+		for s := 1; s <= samplesPerSegment; s++ {
+			t := float64(s) / samplesPerSegment
+			u := 1 - t
+			pts = append(pts, Point{
+				X: u*u*u*p0.X + 3*u*u*t*p1.X + 3*u*t*t*p2.X + t*t*t*p3.X,
+				Y: u*u*u*p0.Y + 3*u*u*t*p1.Y + 3*u*t*t*p2.Y + t*t*t*p3.Y,
+			})
+		}
+	}
+	return newCurve(pts), nil
+}
+
+// CatmullRom builds a Catmull-Rom spline that passes through every point.
+// The first and last points are repeated so the curve starts and ends on them.
+func CatmullRom(ctrl []Point) (*Curve, error) {
+	if len(ctrl) < 2 {
+		return nil, fmt.Errorf("catmull-rom path needs at least 2 points, got %d", len(ctrl))
+	}
+	padded := make([]Point, 0, len(ctrl)+2)
+	padded = append(padded, ctrl[0])
+	padded = append(padded, ctrl...)
+	padded = append(padded, ctrl[len(ctrl)-1])
+
+	pts := []Point{ctrl[0]}
+	for i := 1; i+2 < len(padded); i++ {
+		p0, p1, p2, p3 := padded[i-1], padded[i], padded[i+1], padded[i+2]
+		for s := 1; s <= samplesPerSegment; s++ {
+			t := float64(s) / samplesPerSegment
+			pts = append(pts, Point{
+				X: catmullRom(p0.X, p1.X, p2.X, p3.X, t),
+				Y: catmullRom(p0.Y, p1.Y, p2.Y, p3.Y, t),
+			})
+		}
+	}
+	return newCurve(pts), nil
+}
+
+func catmullRom(p0, p1, p2, p3, t float64) float64 {
+	t2 := t * t
+	t3 := t2 * t
+	return 0.5 * (2*p1 + (p2-p0)*t + (2*p0-5*p1+4*p2-p3)*t2 + (3*p1-p0-3*p2+p3)*t3)
+}
+
+func newCurve(pts []Point) *Curve {
+	dist := make([]float64, len(pts))
+	for i := 1; i < len(pts); i++ {
+		dist[i] = dist[i-1] + math.Hypot(pts[i].X-pts[i-1].X, pts[i].Y-pts[i-1].Y)
+	}
+	return &Curve{points: pts, dist: dist}
+}
+
+// Length returns the arc length of the curve
+func (c *Curve) Length() float64 {
+	return c.dist[len(c.dist)-1]
+}
+
+// At returns the point d units along the curve. Distances outside the
+// curve are clamped to its start or end.
+func (c *Curve) At(d float64) Point {
+	if d <= 0 {
+		return c.points[0]
+	}
+	if d >= c.Length() {
+		return c.points[len(c.points)-1]
+	}
+
+	// Binary search for the segment containing d
+	lo, hi := 0, len(c.dist)-1
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if c.dist[mid] <= d {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	a, b := c.points[lo], c.points[hi]
+	t := (d - c.dist[lo]) / (c.dist[hi] - c.dist[lo])
+	return Point{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t}
+}
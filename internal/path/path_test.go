@@ -0,0 +1,55 @@
+package path
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBezierStraightLine(t *testing.T) {
+	c, err := Bezier([]Point{{0, 0}, {10, 0}, {20, 0}, {30, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(c.Length()-30) > 1e-9 {
+		t.Errorf("Length = %v, want 30", c.Length())
+	}
+	if p := c.At(15); math.Abs(p.X-15) > 1e-9 || p.Y != 0 {
+		t.Errorf("At(15) = %+v, want (15, 0)", p)
+	}
+}
+
+func TestBezierRejectsBadPointCount(t *testing.T) {
+	if _, err := Bezier([]Point{{0, 0}, {1, 1}, {2, 2}, {3, 3}, {4, 4}}); err == nil {
+		t.Error("expected an error for 5 control points")
+	}
+}
+
+func TestCatmullRomPassesThroughPoints(t *testing.T) {
+	ctrl := []Point{{0, 0}, {100, 50}, {200, 0}}
+	c, err := CatmullRom(ctrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := c.At(0); p != ctrl[0] {
+		t.Errorf("start = %+v, want %+v", p, ctrl[0])
+	}
+	if p := c.At(c.Length()); p != ctrl[2] {
+		t.Errorf("end = %+v, want %+v", p, ctrl[2])
+	}
+	// The middle control point sits exactly on a sample
+	found := false
+	for _, p := range c.points {
+		if math.Abs(p.X-100) < 1e-9 && math.Abs(p.Y-50) < 1e-9 {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("curve does not pass through the middle control point")
+	}
+}
+
+func TestNewUnknownKind(t *testing.T) {
+	if _, err := New("hermite", []Point{{0, 0}, {1, 1}}); err == nil {
+		t.Error("expected an error for an unknown spline kind")
+	}
+}
@@ -0,0 +1,56 @@
+// Package ring provides a fixed-size ring buffer. Once full, every new item
+// replaces the oldest, so nothing is ever allocated after New.
+package ring
+
+// Buffer holds up to a fixed number of items, oldest first
+type Buffer[T any] struct {
+	items []T
+	start int // Index of the oldest item
+	n     int
+}
+
+// New returns an empty buffer with room for capacity items
+func New[T any](capacity int) *Buffer[T] {
+	return &Buffer[T]{items: make([]T, capacity)}
+}
+
+// Len returns how many items the buffer holds
+func (b *Buffer[T]) Len() int {
+	return b.n
+}
+
+// Push adds an item, replacing the oldest one if the buffer is full
+func (b *Buffer[T]) Push(item T) {
+	if len(b.items) == 0 {
+		return
+	}
+	if b.n < len(b.items) {
+		b.items[(b.start+b.n)%len(b.items)] = item
+		b.n++
+		return
+	}
+	b.items[b.start] = item
+	b.start = (b.start + 1) % len(b.items)
+}
+
+// At returns the i-th item, counting from the oldest
+func (b *Buffer[T]) At(i int) T {
+	if i < 0 || i >= b.n {
+		panic("ring: index out of range")
+	}
+	return b.items[(b.start+i)%len(b.items)]
+}
+
+// DropOldest removes the oldest item, if there is one
+func (b *Buffer[T]) DropOldest() {
+	if b.n == 0 {
+		return
+	}
+	b.start = (b.start + 1) % len(b.items)
+	b.n--
+}
+
+// Clear empties the buffer
+func (b *Buffer[T]) Clear() {
+	b.start, b.n = 0, 0
+}
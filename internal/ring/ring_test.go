@@ -0,0 +1,41 @@
+package ring
+
+import (
+	"slices"
+	"testing"
+)
+
+func contents(b *Buffer[int]) []int {
+	items := make([]int, b.Len())
+	for i := range items {
+		items[i] = b.At(i)
+	}
+	return items
+}
+
+func TestPushOverwritesOldest(t *testing.T) {
+	b := New[int](3)
+	for i := range 5 {
+		b.Push(i)
+	}
+	if got := contents(b); !slices.Equal(got, []int{2, 3, 4}) {
+		t.Errorf("buffer holds %v, want [2 3 4]", got)
+	}
+}
+
+func TestDropOldest(t *testing.T) {
+	b := New[int](3)
+	for i := range 4 {
+		b.Push(i)
+	}
+	b.DropOldest()
+	b.Push(9)
+	if got := contents(b); !slices.Equal(got, []int{2, 3, 9}) {
+		t.Errorf("buffer holds %v, want [2 3 9]", got)
+	}
+	b.Clear()
+	b.DropOldest()
+	if b.Len() != 0 {
+		t.Errorf("cleared buffer holds %d items", b.Len())
+	}
+}
@@ -0,0 +1,157 @@
+// Package steering implements Reynolds-style steering behaviors for agents
+// moving in a world that may wrap horizontally.
+//
+// Every behavior returns a steering force: the change in velocity the agent
+// would like to make this frame. Forces can be weighted and summed, then
+// applied with Agent.Apply.
+package steering
+
+import (
+	"math"
+
+	"github.com/fabiomsouto/dfndr/internal/utils"
+)
+
+// Agent is the kinematic state the behaviors read
+type Agent struct {
+	Pos, Vel Vec
+	MaxSpeed float64
+	ID       int // Tells apart agents that sit on the same spot
+}
+
+// self reports whether n, found dist away from a, is a itself
+func (a Agent) self(n Agent, dist float64) bool {
+	return dist == 0 && n.ID == a.ID
+}
+
+// Space describes the world agents move in
+type Space struct {
+	Width float64 // Horizontal wrap period, 0 for no wrap
+}
+
+// Offset returns the shortest vector from a to b in this space
+func (s Space) Offset(a, b Vec) Vec {
+	if s.Width <= 0 {
+		return b.Sub(a)
+	}
+	dx, dy := utils.WrapDirection(a.X, a.Y, b.X, b.Y, s.Width)
+	return Vec{dx, dy}
+}
+
+// Apply adds a steering force to the agent's velocity, limiting the force
+// to maxForce and the resulting speed to the agent's MaxSpeed
+func (a Agent) Apply(force Vec, maxForce float64) Vec {
+	return a.Vel.Add(force.Truncate(maxForce)).Truncate(a.MaxSpeed)
+}
+
+// Seek steers the agent straight at target at full speed
+func Seek(s Space, a Agent, target Vec) Vec {
+	desired := s.Offset(a.Pos, target).Normalize().Scale(a.MaxSpeed)
+	return desired.Sub(a.Vel)
+}
+
+// Flee steers the agent straight away from target at full speed
+func Flee(s Space, a Agent, target Vec) Vec {
+	desired := s.Offset(target, a.Pos).Normalize().Scale(a.MaxSpeed)
+	return desired.Sub(a.Vel)
+}
+
+// Arrive behaves like Seek but slows down inside slowRadius so the agent
+// comes to rest on the target instead of overshooting it
+func Arrive(s Space, a Agent, target Vec, slowRadius float64) Vec {
+	offset := s.Offset(a.Pos, target)
+	dist := offset.Len()
+	if dist == 0 {
+		return a.Vel.Scale(-1)
+	}
+	speed := a.MaxSpeed
+	if dist < slowRadius {
+		speed *= dist / slowRadius
+	}
+	desired := offset.Scale(speed / dist)
+	return desired.Sub(a.Vel)
+}
+
+// Pursue seeks the point where target will be if it keeps its velocity. The
+// look-ahead grows with distance and is capped at maxPrediction frames.
+func Pursue(s Space, a Agent, target Agent, maxPrediction float64) Vec {
+	dist := s.Offset(a.Pos, target.Pos).Len()
+	prediction := maxPrediction
+	if a.MaxSpeed > 0 {
+		prediction = math.Min(dist/a.MaxSpeed, maxPrediction)
+	}
+	return Seek(s, a, target.Pos.Add(target.Vel.Scale(prediction)))
+}
+
+// Wander steers the agent towards the given heading, in radians
+func Wander(a Agent, heading float64) Vec {
+	desired := Vec{math.Cos(heading), math.Sin(heading)}.Scale(a.MaxSpeed)
+	return desired.Sub(a.Vel)
+}
+
+// Separation steers away from neighbors closer than radius, pushing harder
+// the closer they are. Neighbors at the agent's exact position get pushed
+// apart sideways, each to the side its ID picks.
+func Separation(s Space, a Agent, neighbors []Agent, radius float64) Vec {
+	var push Vec
+	count := 0
+	for _, n := range neighbors {
+		away := s.Offset(n.Pos, a.Pos)
+		dist := away.Len()
+		if a.self(n, dist) || dist >= radius {
+			continue
+		}
+		if dist == 0 {
+			away, dist = Vec{X: 1}, 1
+			if a.ID < n.ID {
+				away.X = -1
+			}
+		}
+		push = push.Add(away.Scale(1 / (dist * dist)))
+		count++
+	}
+	if count == 0 {
+		return Vec{}
+	}
+	desired := push.Normalize().Scale(a.MaxSpeed)
+	return desired.Sub(a.Vel)
+}
+
+// Alignment steers towards the average heading of neighbors within radius
+func Alignment(s Space, a Agent, neighbors []Agent, radius float64) Vec {
+	var heading Vec
+	count := 0
+	for _, n := range neighbors {
+		dist := s.Offset(a.Pos, n.Pos).Len()
+		if a.self(n, dist) || dist >= radius {
+			continue
+		}
+		heading = heading.Add(n.Vel)
+		count++
+	}
+	if count == 0 {
+		return Vec{}
+	}
+	desired := heading.Normalize().Scale(a.MaxSpeed)
+	return desired.Sub(a.Vel)
+}
+
+// Cohesion steers towards the center of mass of neighbors within radius
+func Cohesion(s Space, a Agent, neighbors []Agent, radius float64) Vec {
+	var center Vec
+	count := 0
+	for _, n := range neighbors {
+		offset := s.Offset(a.Pos, n.Pos)
+		dist := offset.Len()
+		if a.self(n, dist) || dist >= radius {
+			continue
+		}
+		// Accumulate offsets rather than positions so the average holds across the seam
+		center = center.Add(offset)
+		count++
+	}
+	if count == 0 {
+		return Vec{}
+	}
+	return Seek(s, a, a.Pos.Add(center.Scale(1/float64(count))))
+}
@@ -0,0 +1,83 @@
+package steering
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSeekCrossesSeam(t *testing.T) {
+	s := Space{Width: 1000}
+	a := Agent{Pos: Vec{990, 0}, MaxSpeed: 2}
+	f := Seek(s, a, Vec{10, 0})
+	if f.X <= 0 {
+		t.Errorf("Seek across seam = %+v, want force pointing right", f)
+	}
+}
+
+func TestArriveSlowsNearTarget(t *testing.T) {
+	s := Space{}
+	a := Agent{Pos: Vec{0, 0}, MaxSpeed: 4}
+	far := Arrive(s, a, Vec{100, 0}, 50)
+	near := Arrive(s, a, Vec{10, 0}, 50)
+	if near.Len() >= far.Len() {
+		t.Errorf("Arrive near = %v, far = %v, want near < far", near.Len(), far.Len())
+	}
+}
+
+func TestPursueLeadsTarget(t *testing.T) {
+	s := Space{}
+	a := Agent{Pos: Vec{0, 0}, MaxSpeed: 1}
+	target := Agent{Pos: Vec{10, 0}, Vel: Vec{0, 1}}
+	f := Pursue(s, a, target, 30)
+	if f.Y <= 0 {
+		t.Errorf("Pursue = %+v, want force leading the target downwards", f)
+	}
+}
+
+func TestSeparationIgnoresSelfAndPushesAway(t *testing.T) {
+	s := Space{Width: 1000}
+	a := Agent{Pos: Vec{5, 0}, MaxSpeed: 1}
+	neighbors := []Agent{a, {Pos: Vec{995, 0}}}
+	f := Separation(s, a, neighbors, 20)
+	if f.X <= 0 {
+		t.Errorf("Separation = %+v, want push to the right, away from the neighbor across the seam", f)
+	}
+}
+
+func TestSeparationSplitsCoLocatedAgents(t *testing.T) {
+	s := Space{}
+	a := Agent{Pos: Vec{50, 50}, MaxSpeed: 1, ID: 1}
+	b := Agent{Pos: Vec{50, 50}, MaxSpeed: 1, ID: 2}
+	neighbors := []Agent{a, b}
+	fa := Separation(s, a, neighbors, 20)
+	fb := Separation(s, b, neighbors, 20)
+	if fa.Len() == 0 || fb.Len() == 0 {
+		t.Fatalf("Separation = %+v, %+v, want both agents pushed", fa, fb)
+	}
+	if fa.X*fb.X >= 0 {
+		t.Errorf("Separation = %+v, %+v, want pushes in opposite directions", fa, fb)
+	}
+}
+
+func TestCohesionAndAlignment(t *testing.T) {
+	s := Space{}
+	a := Agent{Pos: Vec{0, 0}, MaxSpeed: 1}
+	neighbors := []Agent{
+		{Pos: Vec{0, 10}, Vel: Vec{1, 0}},
+		{Pos: Vec{0, 20}, Vel: Vec{1, 0}},
+	}
+	if f := Cohesion(s, a, neighbors, 50); f.Y <= 0 {
+		t.Errorf("Cohesion = %+v, want pull towards the group below", f)
+	}
+	if f := Alignment(s, a, neighbors, 50); math.Abs(f.X-1) > 1e-9 {
+		t.Errorf("Alignment = %+v, want to match heading (1, 0)", f)
+	}
+}
+
+func TestApplyLimitsForceAndSpeed(t *testing.T) {
+	a := Agent{Vel: Vec{1, 0}, MaxSpeed: 1.5}
+	v := a.Apply(Vec{10, 0}, 2)
+	if v.Len() > 1.5+1e-9 {
+		t.Errorf("Apply speed = %v, want at most 1.5", v.Len())
+	}
+}
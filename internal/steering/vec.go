@@ -0,0 +1,43 @@
+package steering
+
+import "math"
+
+// Vec is a 2D vector used for positions, velocities and steering forces
+type Vec struct {
+	X, Y float64
+}
+
+func (v Vec) Add(o Vec) Vec {
+	return Vec{v.X + o.X, v.Y + o.Y}
+}
+
+func (v Vec) Sub(o Vec) Vec {
+	return Vec{v.X - o.X, v.Y - o.Y}
+}
+
+func (v Vec) Scale(s float64) Vec {
+	return Vec{v.X * s, v.Y * s}
+}
+
+func (v Vec) Len() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+// Normalize returns a unit vector in the direction of v, or the zero vector
+// if v has no length
+func (v Vec) Normalize() Vec {
+	l := v.Len()
+	if l == 0 {
+		return Vec{}
+	}
+	return Vec{v.X / l, v.Y / l}
+}
+
+// Truncate caps the length of v at max, keeping its direction
+func (v Vec) Truncate(max float64) Vec {
+	l := v.Len()
+	if l > max && l > 0 {
+		return v.Scale(max / l)
+	}
+	return v
+}
@@ -0,0 +1,88 @@
+// Package terrain generates seeded, seamlessly wrapping mountain profiles
+package terrain
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/fabiomsouto/dfndr/internal/utils"
+)
+
+// Terrain is a height profile that wraps around every Width units
+type Terrain struct {
+	width   float64
+	step    float64   // World distance between samples
+	heights []float64 // Height above the ground baseline at each sample
+}
+
+// Params controls the shape of a generated profile
+type Params struct {
+	Width     float64 // Wrap period in world units
+	Samples   int     // Number of height samples, rounded up to a power of two
+	MinHeight float64
+	MaxHeight float64
+	Roughness float64 // 0..1, how jagged the profile is. 0.5 is a good default.
+}
+
+// Generate builds a terrain profile from a seed. The same seed and params
+// always produce the same profile.
+func Generate(seed int64, p Params) *Terrain {
+	n := 1
+	for n < p.Samples {
+		n <<= 1
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	// Midpoint displacement over a periodic array, so the last sample
+	// flows back into the first and the seam is invisible
+	h := make([]float64, n)
+	amplitude := 1.0
+	for step := n; step > 1; step /= 2 {
+		half := step / 2
+		for i := 0; i < n; i += step {
+			left, right := h[i], h[(i+step)%n]
+			h[i+half] = (left+right)/2 + (rng.Float64()*2-1)*amplitude
+		}
+		amplitude *= math.Pow(2, -(1 - p.Roughness))
+	}
+
+	// Rescale into the requested height range
+	lo, hi := h[0], h[0]
+	for _, v := range h {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	for i, v := range h {
+		t := 0.5
+		if hi > lo {
+			t = (v - lo) / (hi - lo)
+		}
+		h[i] = p.MinHeight + t*(p.MaxHeight-p.MinHeight)
+	}
+
+	return &Terrain{width: p.Width, step: p.Width / float64(n), heights: h}
+}
+
+// Width returns the wrap period of the profile
+func (t *Terrain) Width() float64 {
+	return t.width
+}
+
+// HeightAt returns the height of the profile at x, wrapping x into the world
+func (t *Terrain) HeightAt(x float64) float64 {
+	pos := utils.Wrap(x, t.width) / t.step
+	i := int(pos)
+	frac := pos - float64(i)
+	a := t.heights[i%len(t.heights)]
+	b := t.heights[(i+1)%len(t.heights)]
+	return a + (b-a)*frac
+}
+
+// MaxHeightIn returns the highest point of the profile between x and x+width
+func (t *Terrain) MaxHeightIn(x, width float64) float64 {
+	highest := math.Max(t.HeightAt(x), t.HeightAt(x+width))
+	for sx := math.Ceil(x/t.step) * t.step; sx < x+width; sx += t.step {
+		highest = math.Max(highest, t.HeightAt(sx))
+	}
+	return highest
+}
@@ -0,0 +1,45 @@
+package terrain
+
+import (
+	"math"
+	"testing"
+)
+
+var params = Params{Width: 10000, Samples: 500, MinHeight: 40, MaxHeight: 160, Roughness: 0.5}
+
+func TestGenerateIsDeterministicAndInRange(t *testing.T) {
+	a := Generate(42, params)
+	b := Generate(42, params)
+	for x := 0.0; x < params.Width; x += 37 {
+		ha, hb := a.HeightAt(x), b.HeightAt(x)
+		if ha != hb {
+			t.Fatalf("same seed differs at x=%v: %v vs %v", x, ha, hb)
+		}
+		if ha < params.MinHeight-1e-9 || ha > params.MaxHeight+1e-9 {
+			t.Fatalf("height %v at x=%v outside [%v, %v]", ha, x, params.MinHeight, params.MaxHeight)
+		}
+	}
+	if len(a.heights) != 512 {
+		t.Errorf("samples = %d, want 512", len(a.heights))
+	}
+}
+
+func TestHeightWrapsSeamlessly(t *testing.T) {
+	tr := Generate(7, params)
+	if d := math.Abs(tr.HeightAt(params.Width-0.001) - tr.HeightAt(0)); d > 0.1 {
+		t.Errorf("height jumps by %v across the seam", d)
+	}
+	if tr.HeightAt(-100) != tr.HeightAt(params.Width-100) {
+		t.Error("negative x does not wrap")
+	}
+}
+
+func TestMaxHeightIn(t *testing.T) {
+	tr := Generate(3, params)
+	got := tr.MaxHeightIn(1000, 100)
+	for x := 1000.0; x <= 1100; x++ {
+		if tr.HeightAt(x) > got+1e-9 {
+			t.Fatalf("MaxHeightIn = %v, but HeightAt(%v) = %v", got, x, tr.HeightAt(x))
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package theme reads theme packs. A pack is a directory with a manifest
+// saying which of its files each sprite, palette, font and sound is loaded
+// from. Whatever a pack leaves out comes from the pack it is laid over.
+package theme
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+)
+
+// ManifestFile is where a pack keeps its manifest
+const ManifestFile = "theme.json"
+
+// Manifest lists the files of a pack by the IDs the game knows them by.
+// Paths are relative to the root of the pack.
+type Manifest struct {
+	Name    string            `json:"name"`
+	Sprites map[string]string `json:"sprites"` // Animation definitions, which name their sheet image
+	Palette string            `json:"palette"`
+	Fonts   map[string]string `json:"fonts"`
+	Sounds  map[string]string `json:"sounds"`
+}
+
+// Parse decodes and validates a manifest
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if m.Name == "" {
+		return nil, fmt.Errorf("theme has no name")
+	}
+	paths := []string{m.Palette}
+	for _, files := range []map[string]string{m.Sprites, m.Fonts, m.Sounds} {
+		for _, path := range files {
+			paths = append(paths, path)
+		}
+	}
+	for _, path := range paths {
+		if path != "" && !fs.ValidPath(path) {
+			return nil, fmt.Errorf("theme %s has invalid path %q", m.Name, path)
+		}
+	}
+	return &m, nil
+}
+
+// File is a file in a pack
+type File struct {
+	FS   fs.FS
+	Path string
+}
+
+// Read returns the contents of the file
+func (f File) Read() ([]byte, error) {
+	return fs.ReadFile(f.FS, f.Path)
+}
+
+// Theme is the set of files a game loads its look and sound from
+type Theme struct {
+	Name    string
+	Sprites map[string]File
+	// Palettes are read in order, each changing only the colors it gives,
+	// so a pack's palette only needs the colors it wants different
+	Palettes []File
+	Fonts    map[string]File
+	Sounds   map[string]File
+}
+
+// Load reads the manifest of the pack in fsys and checks every file it
+// lists is there
+func Load(fsys fs.FS) (*Theme, error) {
+	data, err := fs.ReadFile(fsys, ManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme manifest: %w", err)
+	}
+	m, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse theme manifest: %w", err)
+	}
+
+	t := &Theme{Name: m.Name}
+	files := func(paths map[string]string) (map[string]File, error) {
+		out := make(map[string]File, len(paths))
+		for id, path := range paths {
+			if _, err := fs.Stat(fsys, path); err != nil {
+				return nil, fmt.Errorf("theme %s is missing %s: %w", m.Name, id, err)
+			}
+			out[id] = File{FS: fsys, Path: path}
+		}
+		return out, nil
+	}
+	if t.Sprites, err = files(m.Sprites); err != nil {
+		return nil, err
+	}
+	if t.Fonts, err = files(m.Fonts); err != nil {
+		return nil, err
+	}
+	if t.Sounds, err = files(m.Sounds); err != nil {
+		return nil, err
+	}
+	if m.Palette != "" {
+		palette, err := files(map[string]string{"palette": m.Palette})
+		if err != nil {
+			return nil, err
+		}
+		t.Palettes = []File{palette["palette"]}
+	}
+	return t, nil
+}
+
+// Over lays t over base, the way a pack is laid over the game's own: the
+// result has t's name, and t's files wherever it has them
+func (t *Theme) Over(base *Theme) *Theme {
+	layer := func(under, over map[string]File) map[string]File {
+		out := maps.Clone(under)
+		if out == nil {
+			out = make(map[string]File, len(over))
+		}
+		maps.Copy(out, over)
+		return out
+	}
+	return &Theme{
+		Name:     t.Name,
+		Sprites:  layer(base.Sprites, t.Sprites),
+		Palettes: append(append([]File(nil), base.Palettes...), t.Palettes...),
+		Fonts:    layer(base.Fonts, t.Fonts),
+		Sounds:   layer(base.Sounds, t.Sounds),
+	}
+}
@@ -0,0 +1,94 @@
+package theme
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fabiomsouto/dfndr/internal/assets"
+)
+
+var basePack = fstest.MapFS{
+	"theme.json": {Data: []byte(`{
+		"name": "base",
+		"sprites": {"ship": "animations/ship.json", "gopher": "animations/gopher.json"},
+		"palette": "palette.json",
+		"fonts": {"hud": "hud.ttf"}
+	}`)},
+	"animations/ship.json":   {Data: []byte(`{}`)},
+	"animations/gopher.json": {Data: []byte(`{}`)},
+	"palette.json":           {Data: []byte(`{}`)},
+	"hud.ttf":                {Data: []byte("base font")},
+}
+
+func TestOverKeepsWhatThePackLeavesOut(t *testing.T) {
+	base, err := Load(basePack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pack, err := Load(fstest.MapFS{
+		"theme.json":   {Data: []byte(`{"name": "arcade", "sprites": {"ship": "ship.json"}, "palette": "colors.json"}`)},
+		"ship.json":    {Data: []byte(`{}`)},
+		"colors.json":  {Data: []byte(`{}`)},
+		"unlisted.png": {Data: []byte{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	th := pack.Over(base)
+	if th.Name != "arcade" {
+		t.Errorf("name = %q, want the pack's", th.Name)
+	}
+	if got := th.Sprites["ship"].Path; got != "ship.json" {
+		t.Errorf("ship comes from %s, want the pack's ship.json", got)
+	}
+	if got := th.Sprites["gopher"].Path; got != "animations/gopher.json" {
+		t.Errorf("gopher comes from %s, want the base pack's", got)
+	}
+	if data, err := th.Fonts["hud"].Read(); err != nil || string(data) != "base font" {
+		t.Errorf("hud font reads %q, %v, want the base pack's", data, err)
+	}
+	if len(th.Palettes) != 2 || th.Palettes[0].Path != "palette.json" || th.Palettes[1].Path != "colors.json" {
+		t.Errorf("palettes = %v, want the base one then the pack's", th.Palettes)
+	}
+	if len(base.Palettes) != 1 || base.Sprites["ship"].Path != "animations/ship.json" {
+		t.Error("laying a pack over the base changed the base")
+	}
+}
+
+func TestLoadRejectsBadPacks(t *testing.T) {
+	for name, pack := range map[string]fstest.MapFS{
+		"no manifest":  {},
+		"no name":      {"theme.json": {Data: []byte(`{"sprites": {}}`)}},
+		"missing file": {"theme.json": {Data: []byte(`{"name": "x", "fonts": {"hud": "hud.ttf"}}`)}},
+		"escapes pack": {"theme.json": {Data: []byte(`{"name": "x", "palette": "../palette.json"}`)}},
+	} {
+		if _, err := Load(pack); err == nil {
+			t.Errorf("%s: loaded without error", name)
+		}
+	}
+}
+
+func TestEmbeddedPacks(t *testing.T) {
+	base, err := Load(assets.Assets)
+	if err != nil {
+		t.Fatalf("default pack: %v", err)
+	}
+	entries, err := fs.ReadDir(assets.Assets, "themes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		fsys, _ := fs.Sub(assets.Assets, "themes/"+entry.Name())
+		pack, err := Load(fsys)
+		if err != nil {
+			t.Errorf("%s: %v", entry.Name(), err)
+			continue
+		}
+		th := pack.Over(base)
+		if len(th.Sprites) != len(base.Sprites) || len(th.Fonts) != len(base.Fonts) {
+			t.Errorf("%s: sprites or fonts missing after laying it over the default pack", entry.Name())
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import "math"
+
+// Wrap folds v into the half-open range [0, period)
+func Wrap(v, period float64) float64 {
+	v = math.Mod(v, period)
+	if v < 0 {
+		v += period
+	}
+	return v
+}
+
+// WrapDelta returns the shortest signed offset that takes from to to on a
+// ring of the given period. The result lies in [-period/2, period/2).
+func WrapDelta(from, to, period float64) float64 {
+	d := Wrap(to-from, period)
+	if d >= period/2 {
+		d -= period
+	}
+	return d
+}
+
+// WrapDirection returns the shortest vector from (x1, y1) to (x2, y2) in a
+// world that wraps horizontally every width units
+func WrapDirection(x1, y1, x2, y2, width float64) (float64, float64) {
+	return WrapDelta(x1, x2, width), y2 - y1
+}
+
+// WrapDistance returns the length of the shortest path between two points in
+// a world that wraps horizontally every width units
+func WrapDistance(x1, y1, x2, y2, width float64) float64 {
+	dx, dy := WrapDirection(x1, y1, x2, y2, width)
+	return math.Hypot(dx, dy)
+}
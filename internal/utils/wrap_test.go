@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	cases := []struct {
+		v, period, want float64
+	}{
+		{0, 100, 0},
+		{50, 100, 50},
+		{100, 100, 0},
+		{130, 100, 30},
+		{-10, 100, 90},
+		{-250, 100, 50},
+	}
+	for _, c := range cases {
+		if got := Wrap(c.v, c.period); got != c.want {
+			t.Errorf("Wrap(%v, %v) = %v, want %v", c.v, c.period, got, c.want)
+		}
+	}
+}
+
+func TestWrapDeltaTakesShortWayAcrossSeam(t *testing.T) {
+	const width = 10000
+	if got := WrapDelta(9990, 10, width); got != 20 {
+		t.Errorf("WrapDelta across right seam = %v, want 20", got)
+	}
+	if got := WrapDelta(10, 9990, width); got != -20 {
+		t.Errorf("WrapDelta across left seam = %v, want -20", got)
+	}
+	if got := WrapDelta(100, 400, width); got != 300 {
+		t.Errorf("WrapDelta without seam = %v, want 300", got)
+	}
+}
+
+func TestWrapDistance(t *testing.T) {
+	got := WrapDistance(9998, 0, 1, 4, 10000)
+	if math.Abs(got-5) > 1e-9 {
+		t.Errorf("WrapDistance = %v, want 5", got)
+	}
+}
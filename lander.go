@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+
+	"github.com/fabiomsouto/dfndr/internal/fsm"
+	"github.com/fabiomsouto/dfndr/internal/steering"
+	"github.com/fabiomsouto/dfndr/internal/utils"
+)
+
+// Lander AI states
+const (
+	aiHunt   = "hunt"   // Fly down to the gopher the world picked for us
+	aiAbduct = "abduct" // Climb to the top of the world with a gopher in tow
+)
+
+const (
+	// Distance from a gopher at which a lander can grab it
+	grabRange = 12
+	// Landers that climb this high with a gopher turn into mutants
+	abductCeiling = 0
+	// Distance from its victim at which a lander slows down
+	landerApproachRadius = 120
+)
+
+var landerAI = fsm.NewDefinition(
+	&fsm.State[*Enemy]{
+		Name: aiHunt,
+		Update: func(e *Enemy, _ int) string {
+			if e.target == nil {
+				return ""
+			}
+			if e.target.state != gopherWalking {
+				e.target = nil
+				return ""
+			}
+			// Grab the gopher once we're hovering right on top of it
+			dx, dy := utils.WrapDirection(e.x+enemyWidth/2, e.y+enemyHeight, e.target.x+gopherWidth/2, e.target.y, WorldWidth)
+			if math.Abs(dx) < grabRange && math.Abs(dy) < grabRange {
+				e.captive = e.target
+				e.target = nil
+				e.captive.Grab(e)
+				return aiAbduct
+			}
+			return ""
+		},
+	},
+	&fsm.State[*Enemy]{
+		Name: aiAbduct,
+		Update: func(e *Enemy, _ int) string {
+			if e.y <= abductCeiling {
+				e.mutate()
+			}
+			return ""
+		},
+	},
+)
+
+// huntTarget flies to just above the lander's victim, or wanders while it has none
+func huntTarget(e *Enemy, _ []steering.Agent) steering.Vec {
+	if e.target == nil {
+		return steering.Wander(e.agent(), e.wanderAngle)
+	}
+	above := steering.Vec{
+		X: e.target.x + (gopherWidth-enemyWidth)/2,
+		Y: e.target.y - enemyHeight,
+	}
+	return steering.Arrive(worldSpace, e.agent(), above, landerApproachRadius)
+}
+
+// ascend climbs straight up
+func ascend(e *Enemy, _ []steering.Agent) steering.Vec {
+	a := e.agent()
+	return steering.Vec{Y: -a.MaxSpeed}.Sub(a.Vel)
+}
+
+// mutate turns a lander that made it to the top with its captive into a
+// mutant. The gopher doesn't survive the experience.
+func (e *Enemy) mutate() {
+	if e.captive != nil {
+		e.captive.Kill()
+		e.captive = nil
+	}
+	e.setKind(mutantKind)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/fabiomsouto/dfndr/internal/steering"
+)
+
+func TestLanderMutatesAtCeiling(t *testing.T) {
+	pack, err := loadTheme("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	library, err := LoadAssets(pack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewport := NewViewport(ScreenWidth, ScreenHeight, WorldWidth, defaultWorldHeight)
+	player := NewPlayer(viewport, library)
+	e := NewEnemy(WorldWidth/2, 40, 0, 0, landerKind, player, viewport, 1, library)
+	g := NewGopher(e.x, e.y+enemyHeight, viewport, library.Sprite(SpriteGopher))
+	e.captive = g
+	g.Grab(e)
+	e.brain.Set(e, aiAbduct)
+
+	for range 10 * ScreenHeight {
+		e.Update([]steering.Agent{e.agent()})
+		if e.kind == mutantKind {
+			break
+		}
+		if e.y > 40 {
+			t.Fatalf("lander carrying a gopher dropped to y=%v", e.y)
+		}
+	}
+	if e.kind != mutantKind {
+		t.Fatalf("lander at y=%v didn't mutate", e.y)
+	}
+	if g.Alive() {
+		t.Error("captive survived the mutation")
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	lightTextureSize = 128
+
+	bulletLightRadius    = 140
+	bulletLightIntensity = 0.9
+	// Particles light up the area around them by this many times their size
+	particleLightScale     = 8
+	particleLightMin       = 24 // Smallest radius of a particle's light
+	particleLightIntensity = 0.12
+
+	// Fraction of the light that also spills onto the screen as a glow, so
+	// colored light shows up against the dark sky too
+	lightGlow = 0.15
+)
+
+var (
+	// lightTexture is a white spot that fades out from its middle
+	lightTexture = newLightTexture(lightTextureSize)
+
+	// blendMultiply multiplies the colors already drawn by the source,
+	// leaving their alpha alone
+	blendMultiply = ebiten.Blend{
+		BlendFactorSourceRGB:        ebiten.BlendFactorDestinationColor,
+		BlendFactorSourceAlpha:      ebiten.BlendFactorZero,
+		BlendFactorDestinationRGB:   ebiten.BlendFactorZero,
+		BlendFactorDestinationAlpha: ebiten.BlendFactorOne,
+		BlendOperationRGB:           ebiten.BlendOperationAdd,
+		BlendOperationAlpha:         ebiten.BlendOperationAdd,
+	}
+)
+
+func newLightTexture(size int) *ebiten.Image {
+	pixels := image.NewRGBA(image.Rect(0, 0, size, size))
+	half := float64(size) / 2
+	for y := range size {
+		for x := range size {
+			d := math.Hypot(float64(x)+0.5-half, float64(y)+0.5-half) / half
+			v := uint8(255 * math.Pow(max(0, 1-d), 2))
+			pixels.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: v})
+		}
+	}
+	return ebiten.NewImageFromImage(pixels)
+}
+
+// Lighting lights the terrain and enemies with the light of bullets,
+// explosions and engine exhaust. What it lights is drawn on a layer of its
+// own, which is multiplied by a light buffer: the sector's ambient light
+// with every light added on top.
+type Lighting struct {
+	layer, buffer *ebiten.Image // Allocated as big as the largest screen seen so far
+
+	// Reused every frame to batch the lights into a single draw call
+	vertices []ebiten.Vertex
+	indices  []uint16
+}
+
+func NewLighting() *Lighting {
+	return &Lighting{}
+}
+
+// Begin returns the layer to draw what the lights fall on, sized to screen
+func (l *Lighting) Begin(screen *ebiten.Image) *ebiten.Image {
+	size := screen.Bounds().Size()
+	if l.layer == nil || !size.In(l.layer.Bounds()) {
+		// Grow a little past the screen, so zooming doesn't reallocate every frame
+		w, h := size.X+size.X/4, size.Y+size.Y/4
+		l.layer = ebiten.NewImage(w, h)
+		l.buffer = ebiten.NewImage(w, h)
+	}
+	layer := l.layer.SubImage(image.Rectangle{Max: size}).(*ebiten.Image)
+	layer.Clear()
+	return layer
+}
+
+// End lights the layer returned by Begin and draws it onto screen
+func (l *Lighting) End(screen *ebiten.Image, world *World) {
+	size := screen.Bounds().Size()
+	layer := l.layer.SubImage(image.Rectangle{Max: size}).(*ebiten.Image)
+	buffer := l.buffer.SubImage(image.Rectangle{Max: size}).(*ebiten.Image)
+
+	ambient := uint8(255 * world.sector.ambient)
+	buffer.Fill(color.RGBA{R: ambient, G: ambient, B: ambient, A: 255})
+	l.gather(world, float64(size.X), float64(size.Y))
+	buffer.DrawTriangles(l.vertices, l.indices, lightTexture, &ebiten.DrawTrianglesOptions{Blend: ebiten.BlendLighter})
+
+	layer.DrawImage(buffer, &ebiten.DrawImageOptions{Blend: blendMultiply})
+	screen.DrawImage(layer, nil)
+
+	for i := range l.vertices {
+		l.vertices[i].ColorA *= lightGlow
+	}
+	screen.DrawTriangles(l.vertices, l.indices, lightTexture, &ebiten.DrawTrianglesOptions{Blend: ebiten.BlendLighter})
+}
+
+// gather batches every light on a screen of the given size
+func (l *Lighting) gather(world *World, width, height float64) {
+	l.vertices = l.vertices[:0]
+	l.indices = l.indices[:0]
+	v := world.viewport
+	p := world.player
+	for _, b := range p.bullets {
+		if b.active {
+			x, y := v.WorldToScreen(v.Lerp(b.prevX, b.prevY, b.x, b.y))
+			l.add(x, y, bulletLightRadius, p.trailStyle.color(b.trailHue, 0), bulletLightIntensity, width, height)
+		}
+	}
+	// Explosions and exhaust are made of particles, each a small light
+	for _, pt := range world.particles.pool.Particles() {
+		x, y := v.WorldToScreen(v.Lerp(pt.PrevX, pt.PrevY, pt.X, pt.Y))
+		clr := pt.Color()
+		radius := max(particleLightMin, pt.CurrentSize()*particleLightScale)
+		l.add(x, y, radius, clr, particleLightIntensity*float64(clr.A)/255, width, height)
+	}
+}
+
+// add batches a light, unless it falls entirely off screen
+func (l *Lighting) add(x, y, radius float64, clr color.RGBA, intensity, width, height float64) {
+	if x < -radius || x > width+radius || y < -radius || y > height+radius || intensity <= 0 {
+		return
+	}
+	if len(l.vertices)+4 > math.MaxUint16 {
+		return
+	}
+	base := uint16(len(l.vertices))
+	r, g, b := float32(clr.R)/255, float32(clr.G)/255, float32(clr.B)/255
+	for _, c := range [4][2]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+		l.vertices = append(l.vertices, ebiten.Vertex{
+			DstX:   float32(x + c[0]*radius),
+			DstY:   float32(y + c[1]*radius),
+			SrcX:   float32((c[0] + 1) / 2 * lightTextureSize),
+			SrcY:   float32((c[1] + 1) / 2 * lightTextureSize),
+			ColorR: r, ColorG: g, ColorB: b,
+			ColorA: float32(intensity),
+		})
+	}
+	l.indices = append(l.indices, base, base+1, base+2, base, base+2, base+3)
+}
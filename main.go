@@ -1,52 +1,194 @@
 package main
 
 import (
+	"flag"
 	"log"
+	"math"
+	"math/rand"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 const (
 	ScreenWidth  = 1024
 	ScreenHeight = 768
 	Level        = 1
+
+	// Height of the world unless set with -height
+	defaultWorldHeight = 2 * ScreenHeight
 )
 
 type Game struct {
-	player *Player
-	world  *World
+	viewport *Viewport
+	canvas   *ebiten.Image // The world is drawn here, then zoomed and shaken onto the screen
+	player   *Player
+	world    *World
+	scanner  *Scanner
+	hud      *HUD
+	camera   *Camera
+	post     *PostProcessor
+	display  *Display
+	renderer Renderer
+	render   RenderMode // Switched with F9
+	themes   []string   // Theme packs to cycle through with F2
+	theme    int
+	debug    bool      // Toggled with F3, shows the enemy AI states
+	lastTick time.Time // When the simulation last ticked, to draw between ticks
 }
 
-func newGame() *Game {
-	viewport := NewViewport(ScreenWidth, ScreenHeight, WorldWidth)
-	player := NewPlayer(viewport)
-	world := NewWorld(player, viewport, Level)
+func newGame(library *AssetManager, worldHeight float64) *Game {
+	sector := GenerateSector(rand.Int63(), Level, worldHeight, library.Formations())
+	viewport := NewViewport(ScreenWidth, ScreenHeight, WorldWidth, sector.height)
+	player := NewPlayer(viewport, library)
+	world := NewWorld(player, viewport, sector, library)
 
 	return &Game{
-		player: player,
-		world:  world,
+		viewport: viewport,
+		canvas:   ebiten.NewImage(int(ScreenWidth/minZoom)+1, int(ScreenHeight/minZoom)+1),
+		player:   player,
+		world:    world,
+		scanner:  NewScanner(),
+		hud:      NewHUD(library),
+		camera:   NewCamera(viewport, defaultCameraConfig),
+		post:     NewPostProcessor(library),
+		display:  NewDisplay(),
+		renderer: NewRenderer(RenderSprites),
+	}
+}
+
+// reset starts a new game once the player runs out of lives
+func (g *Game) reset() {
+	debug, effects, post, display := g.debug, g.viewport.effects, g.post, g.display
+	renderer, render := g.renderer, g.render
+	themes, theme := g.themes, g.theme
+	*g = *newGame(g.world.library, g.world.sector.height)
+	g.renderer, g.render = renderer, render
+	g.themes, g.theme = themes, theme
+	g.debug = debug
+	g.viewport.effects = effects
+	g.post = post
+	g.display = display
+}
+
+// nextTheme switches to the next theme pack without restarting the run
+func (g *Game) nextTheme() {
+	g.theme = (g.theme + 1) % len(g.themes)
+	t, err := loadTheme(g.themes[g.theme])
+	if err == nil {
+		err = g.world.library.UseTheme(t)
+	}
+	if err != nil {
+		log.Printf("failed to switch theme: %v", err)
+		return
 	}
+	g.hud = NewHUD(g.world.library)
+	g.world.applyPalette()
 }
 
 func (g *Game) Update() error {
-	g.world.Update()
+	if inpututil.IsKeyJustPressed(ebiten.KeyF2) {
+		g.nextTheme()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		g.debug = !g.debug
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF4) {
+		g.viewport.effects = g.viewport.effects.Next()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF9) {
+		g.render = g.render.Next()
+		g.renderer = NewRenderer(g.render)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF10) {
+		g.display.Cycle()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF11) {
+		g.display.ToggleFullscreen()
+	}
+	for e, key := range postEffectKeys {
+		if inpututil.IsKeyJustPressed(key) {
+			g.post.Toggle(PostEffect(e))
+		}
+	}
+
+	g.lastTick = time.Now()
+	g.viewport.Snapshot()
+	g.player.Snapshot()
+	g.world.Snapshot()
+
+	g.hud.Update()
+	frozen := g.viewport.Frozen()
+	g.viewport.UpdateEffects(math.Hypot(g.player.vx, g.player.vy))
+	if frozen {
+		return nil
+	}
 	g.player.Update()
+	g.camera.Follow(g.player)
+	g.world.Update()
+	if g.player.lives <= 0 {
+		g.reset()
+	}
 	return nil
 }
 
-func (g *Game) Draw(screen *ebiten.Image) {
-	g.world.Draw(screen)
-	g.player.Draw(screen)
+func (g *Game) Draw(window *ebiten.Image) {
+	// The simulation ticks at a fixed rate, but the screen may refresh
+	// faster. Draw everything part of the way into the latest tick.
+	alpha := time.Since(g.lastTick).Seconds() * float64(ebiten.TPS())
+	g.viewport.SetAlpha(min(1, alpha))
+	defer g.viewport.SetAlpha(1)
+
+	screen := g.display.Screen(window)
+	frame := g.post.Frame(screen, g.viewport.trauma)
+	g.canvas.Clear()
+	canvas := g.viewport.Canvas(g.canvas)
+	g.renderer.Draw(canvas, g.world)
+	if g.debug {
+		g.world.DrawDebug(canvas)
+	}
+	g.viewport.Present(frame, canvas)
+	g.scanner.Draw(frame, g.world)
+	g.hud.Draw(frame, g.world)
+	g.post.Apply(screen, frame)
+	g.display.Present(window, screen)
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return ScreenWidth, ScreenHeight
+	width, height := g.display.Layout(outsideWidth, outsideHeight)
+	g.fit(float64(g.display.width), float64(g.display.height))
+	return width, height
+}
+
+// fit sizes the view of the world to the game's screen
+func (g *Game) fit(width, height float64) {
+	v := g.viewport
+	if width == v.baseWidth && height == v.baseHeight {
+		return
+	}
+	v.Resize(width, height)
+	g.canvas = ebiten.NewImage(int(width/minZoom)+1, int(height/minZoom)+1)
 }
 
 func main() {
 	ebiten.SetWindowSize(ScreenWidth, ScreenHeight)
 	ebiten.SetWindowTitle("Go Defender")
-	game := newGame()
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	themeName := flag.String("theme", "", "theme pack to play with: arcade, highcontrast or the path to a pack directory")
+	worldHeight := flag.Float64("height", defaultWorldHeight, "height of the world in pixels, at least the screen height")
+	flag.Parse()
+
+	t, err := loadTheme(*themeName)
+	if err != nil {
+		log.Fatalf("failed to load theme: %v", err)
+	}
+	library, err := LoadAssets(t)
+	if err != nil {
+		log.Fatalf("failed to load assets: %v", err)
+	}
+	game := newGame(library, max(*worldHeight, ScreenHeight))
+	game.themes, game.theme = themeChoices(*themeName)
 	if err := ebiten.RunGame(game); err != nil {
 		log.Fatalf("something went terribly wrong: %v", err)
 	}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/fabiomsouto/dfndr/internal/assets"
+	"github.com/fabiomsouto/dfndr/internal/particles"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	particlesDir = "particles"
+	// Most particles alive at once. Each one is a quad, so this has to stay
+	// within what a batch with 16-bit indices can address.
+	maxParticles = 4096
+)
+
+// ParticleSystem owns every particle in the world and the effects that
+// spawn them, loaded from internal/assets/particles
+type ParticleSystem struct {
+	pool     *particles.Pool
+	emitters map[string]*particles.Emitter
+
+	// Reused every frame to draw all particles in one batch
+	vertices []ebiten.Vertex
+	indices  []uint16
+}
+
+func NewParticleSystem(emitters map[string]*particles.Emitter) *ParticleSystem {
+	return &ParticleSystem{
+		pool:     particles.NewPool(maxParticles, rand.Int63()),
+		emitters: emitters,
+		vertices: make([]ebiten.Vertex, 0, 4*maxParticles),
+		indices:  make([]uint16, 0, 6*maxParticles),
+	}
+}
+
+// loadEmitters reads every emitter definition from the embedded assets
+func loadEmitters() (map[string]*particles.Emitter, error) {
+	entries, err := assets.Assets.ReadDir(particlesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list particle effects in embedded assets: %w", err)
+	}
+
+	emitters := make(map[string]*particles.Emitter, len(entries))
+	for _, entry := range entries {
+		data, err := assets.Assets.ReadFile(particlesDir + "/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read particle effect %s: %w", entry.Name(), err)
+		}
+		e, err := particles.Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load particle effect %s: %w", entry.Name(), err)
+		}
+		emitters[strings.TrimSuffix(entry.Name(), ".json")] = e
+	}
+	return emitters, nil
+}
+
+// Emitter returns the named effect. Asking for one that doesn't exist is a
+// programming error.
+func (s *ParticleSystem) Emitter(name string) *particles.Emitter {
+	e, ok := s.emitters[name]
+	if !ok {
+		panic(fmt.Sprintf("particles: unknown effect %q", name))
+	}
+	return e
+}
+
+// Burst sets off the named effect at a world position
+func (s *ParticleSystem) Burst(name string, x, y, heading float64) {
+	s.pool.Burst(s.Emitter(name), x, y, heading)
+}
+
+// Source returns a continuous emitter of the named effect
+func (s *ParticleSystem) Source(name string) *particles.Source {
+	return &particles.Source{Emitter: s.Emitter(name)}
+}
+
+func (s *ParticleSystem) Update() {
+	s.pool.Update()
+}
+
+// Draw draws every particle on screen in a single batch
+func (s *ParticleSystem) Draw(screen *ebiten.Image, viewport *Viewport) {
+	s.vertices = s.vertices[:0]
+	s.indices = s.indices[:0]
+	for _, p := range s.pool.Particles() {
+		size := p.CurrentSize()
+		x, y := viewport.WorldToScreen(viewport.Lerp(p.PrevX, p.PrevY, p.X, p.Y))
+		if x < -size || x > viewport.width+size || y < -size || y > viewport.height+size {
+			continue
+		}
+		s.vertices, s.indices = appendRotatedQuad(s.vertices, s.indices, x, y, size, p.Rotation, p.Color())
+	}
+	screen.DrawTriangles(s.vertices, s.indices, whitePixel, nil)
+}
@@ -1,17 +1,18 @@
 package main
 
 import (
-	"bytes"
-	"image"
 	"image/color"
 	_ "image/png" // Register PNG decoder
 	"log"
 	"math"
 	"math/rand"
 
-	"github.com/fabiomsouto/dfndr/internal/assets"
+	"github.com/fabiomsouto/dfndr/internal/anim"
+	"github.com/fabiomsouto/dfndr/internal/ring"
+	"github.com/fabiomsouto/dfndr/internal/steering"
 	"github.com/fabiomsouto/dfndr/internal/utils"
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
@@ -20,80 +21,126 @@ const (
 	shipHeight = 49
 
 	shipStartPosX = 60
-	shipStartPosY = 100
+	shipStartPosY = ScreenHeight / 2 // Measured up from the bottom of the world
 	shipMaxSpeed  = 20
 
 	thrustForce = 1
 	dragFactor  = 0.95
 
-	bulletsMax = 20 // Max active bullets
+	bulletsMax  = 20 // Max active bullets
+	bulletSpeed = shipMaxSpeed + 10
+
+	startLives         = 3
+	maxShield          = 100
+	invulnerableFrames = 120 // Grace period after losing a life
+	startBombs         = 3
 )
 
 type Player struct {
 	x, y         float64 // world coordinates
+	prevX, prevY float64 // Position at the previous tick, to draw between ticks
 	vx, vy       float64
-	image        *ebiten.Image
+	sprites      *SpriteSheet
+	anim         anim.Animator
 	bullets      []*Bullet
 	viewport     *Viewport
 	spaceWasDown bool // Track previous state of space key
 	facingLeft   bool // Track which direction the player is facing
-}
-
-type TrailPoint struct {
-	x, y float64
+	lives        int
+	shield       float64 // Damage the ship can take before losing a life
+	invulnerable int     // Frames left of the grace period after losing a life
+	bombs        int
+	detonate     bool // Set when the player sets off a smart bomb, cleared by the world
+	thrusting    bool // Whether the engine is firing this frame
+	trailStyle   *TrailStyle
+
+	// Reused every frame to batch the bullet trails into a single draw call
+	trailVertices []ebiten.Vertex
+	trailIndices  []uint16
 }
 
 type Bullet struct {
 	x, y     float64
+	prevX    float64 // Position at the previous tick, to draw between ticks
+	prevY    float64
+	vx, vy   float64
 	right    bool
 	active   bool
-	trail    []TrailPoint
+	trail    *ring.Buffer[TrailPoint]
 	trailHue float64 // Tracks the current hue for color morphing
 }
 
-func NewPlayer(viewport *Viewport) *Player {
-	img := shipImg()
+func NewPlayer(viewport *Viewport, library *AssetManager) *Player {
 	bullets := make([]*Bullet, bulletsMax)
 	for i := range bullets {
-		bullets[i] = &Bullet{active: false}
+		bullets[i] = &Bullet{active: false, trail: ring.New[TrailPoint](trailPoints)}
 	}
-	return &Player{
+	p := &Player{
 		x:            shipStartPosX,
-		y:            shipStartPosY,
+		y:            viewport.worldHeight - shipStartPosY,
 		vx:           0,
 		vy:           0,
-		image:        img,
+		sprites:      library.Sprite(SpriteShip),
 		bullets:      bullets,
 		viewport:     viewport,
 		spaceWasDown: false,
 		facingLeft:   false, // Start facing right
+		lives:        startLives,
+		shield:       maxShield,
+		bombs:        startBombs,
+		trailStyle:   &rainbowTrail,
 	}
+	p.anim.Play(p.sprites.Sheet, "idle")
+	return p
 }
 
 func (p *Player) Position() (float64, float64) {
 	return p.x, p.y
 }
 
-func shipImg() *ebiten.Image {
-	// load image from embedded filesystem
-	data, err := assets.Assets.ReadFile("ship.png")
-	if err != nil {
-		log.Fatalf("failed to read ship.png from embedded assets: %v", err)
+// Damage wears down the ship's shield. When it gives out the ship loses a
+// life and gets a fresh shield and a short grace period.
+func (p *Player) Damage(amount float64) {
+	if p.invulnerable > 0 || p.lives <= 0 {
+		return
 	}
+	p.shield -= amount
+	p.anim.Play(p.sprites.Sheet, "hit")
+	p.viewport.AddTrauma(damageTrauma * min(1, amount/maxShield*4))
+	if p.shield <= 0 {
+		p.lives--
+		p.shield = maxShield
+		p.invulnerable = invulnerableFrames
+		p.viewport.AddTrauma(lifeLostTrauma)
+	}
+}
 
-	img, _, err := image.Decode(bytes.NewReader(data))
-	if err != nil {
-		log.Fatalf("failed to decode ship image: %v", err)
+// agent returns the player's state for enemy steering behaviors
+func (p *Player) agent() steering.Agent {
+	return steering.Agent{
+		Pos:      steering.Vec{X: p.x, Y: p.y},
+		Vel:      steering.Vec{X: p.vx, Y: p.vy},
+		MaxSpeed: shipMaxSpeed,
 	}
+}
 
-	return ebiten.NewImageFromImage(img)
+// Snapshot remembers where the ship and its bullets are before a
+// simulation tick moves them
+func (p *Player) Snapshot() {
+	p.prevX, p.prevY = p.x, p.y
+	for _, b := range p.bullets {
+		b.prevX, b.prevY = b.x, b.y
+	}
 }
 
 func (p *Player) Update() {
+	p.thrusting = false
+
 	// Apply thrust
 	// Right movement
 	if ebiten.IsKeyPressed(ebiten.KeyArrowRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
 		p.vx += thrustForce
+		p.thrusting = true
 		if p.vx > 0.1 { // Only update facing direction when we have meaningful movement
 			p.facingLeft = false
 		}
@@ -101,6 +148,7 @@ func (p *Player) Update() {
 	// Left movement
 	if ebiten.IsKeyPressed(ebiten.KeyArrowLeft) || ebiten.IsKeyPressed(ebiten.KeyA) {
 		p.vx -= thrustForce
+		p.thrusting = true
 		if p.vx < -0.1 { // Only update facing direction when we have meaningful movement
 			p.facingLeft = true
 		}
@@ -114,6 +162,12 @@ func (p *Player) Update() {
 		p.vy += thrustForce
 	}
 
+	// Smart bomb
+	if inpututil.IsKeyJustPressed(ebiten.KeyB) && p.bombs > 0 {
+		p.bombs--
+		p.detonate = true
+	}
+
 	// Handle bullet firing with simple key press detection
 	spaceIsDown := ebiten.IsKeyPressed(ebiten.KeySpace)
 	if spaceIsDown && !p.spaceWasDown { // Only fire on the initial press
@@ -128,76 +182,45 @@ func (p *Player) Update() {
 				}
 				b.y = p.y + 36          // Center vertically on the ship
 				b.right = !p.facingLeft // Fire in the direction the player is facing
+				b.vx = bulletSpeed
+				if p.facingLeft {
+					b.vx = -bulletSpeed
+				}
+				b.vy = 0
 				b.active = true
-				b.trail = make([]TrailPoint, 0, 50) // Preallocate space for 50 points
-				b.trailHue = rand.Float64() * 360   // Random starting hue
+				b.prevX, b.prevY = b.x, b.y
+				b.trail.Clear()
+				b.trailHue = rand.Float64() * 360 // Random starting hue
+				p.anim.Restart(p.sprites.Sheet, "attack")
 				break
 			}
 		}
 	}
 	p.spaceWasDown = spaceIsDown // Update previous state
 
-	// Update bullets
-	for _, b := range p.bullets {
-		if b.active {
-			// Store previous position
-			prevX, prevY := b.x, b.y
-
-			if b.right {
-				b.x += shipMaxSpeed + 10
-				// Deactivate if out of viewport bounds
-				bvx, _ := p.viewport.WorldToScreen(b.x, b.y)
-				if b.x < 0 || bvx > p.viewport.width-10 { // -10 to give some margin
-					b.active = false
-				}
+	if p.invulnerable > 0 {
+		p.invulnerable--
+	}
 
-				// Remove trail points that are too far behind
-				if len(b.trail) > 0 {
-					for i, point := range b.trail {
-						if b.x-point.x > 200 { // Remove points more than 200 pixels behind
-							b.trail = b.trail[i+1:]
-							break
-						}
-					}
-				}
-			} else {
-				b.x -= shipMaxSpeed + 10
-				// Deactivate if out of viewport bounds
-				bvx, _ := p.viewport.WorldToScreen(b.x, b.y)
-				if b.x < 0 || bvx < -10 { // -10 to give some margin
-					b.active = false
-				}
+	// Go back to idle once the muzzle flash or hit has played
+	if p.anim.Done() {
+		p.anim.Play(p.sprites.Sheet, "idle")
+	}
+	p.anim.Update()
 
-				// Remove trail points that are too far behind
-				if len(b.trail) > 0 {
-					for i, point := range b.trail {
-						if point.x-b.x > 600 { // Remove points more than 600 pixels behind
-							b.trail = b.trail[i+1:]
-							break
-						}
-					}
-				}
-			} // Update trail
-			if len(b.trail) == 0 || math.Hypot(b.x-prevX, b.y-prevY) > 5 {
-				// Add slight randomness to y position for irregular effect
-				// trailY := b.y + (rand.Float64()*2-1)*2
-				// TODO: dont like the effect right now, I'll revisit later
-				trailY := b.y
-
-				// Keep track of current hue but don't assign color yet
-				b.trailHue = math.Mod(b.trailHue+2, 360)
-
-				b.trail = append(b.trail, TrailPoint{
-					x: b.x,
-					y: trailY,
-				})
-
-				// Keep trail at a reasonable length
-				if len(b.trail) > 50 {
-					b.trail = b.trail[1:]
-				}
-			}
+	// Update bullets
+	for _, b := range p.bullets {
+		if !b.active {
+			continue
+		}
+		b.x = utils.Wrap(b.x+b.vx, WorldWidth)
+		b.y += b.vy
+		// Deactivate once out of the viewport, with some margin
+		bvx, _ := p.viewport.WorldToScreen(b.x, b.y)
+		if (b.right && bvx > p.viewport.width-10) || (!b.right && bvx < -10) {
+			b.active = false
 		}
+		b.updateTrail(p.trailStyle)
 	}
 
 	// Apply drag
@@ -228,33 +251,25 @@ func (p *Player) Update() {
 	p.x += p.vx
 	p.y += p.vy
 
-	// keep player within screen bounds vertically
+	// keep player within world bounds vertically
 	if p.y < 0 {
 		p.y = 0
 		p.vy = 0
 	}
-	if p.y > ScreenHeight-shipHeight {
-		p.y = ScreenHeight - shipHeight
+	if p.y > p.viewport.worldHeight-shipHeight {
+		p.y = p.viewport.worldHeight - shipHeight
 		p.vy = 0
 	}
 
 	// wrap around the world horizontally
-	if p.x < -shipWidth {
-		p.x = WorldWidth
-	}
-	if p.x > WorldWidth {
-		p.x = 0
-	}
-
-	// Update viewport to follow player
-	p.viewport.Follow(p.x, p.y)
+	p.x = utils.Wrap(p.x, WorldWidth)
 
 	log.Printf("Player position: (%.2f, %.2f), velocity: (%.2f, %.2f)", p.x, p.y, p.vx, p.vy)
 }
 
 func (p *Player) Draw(screen *ebiten.Image) {
 	// Convert world coordinates to screen coordinates
-	screenX, screenY := p.viewport.WorldToScreen(p.x, p.y)
+	screenX, screenY := p.viewport.WorldToScreen(p.viewport.Lerp(p.prevX, p.prevY, p.x, p.y))
 
 	op := &ebiten.DrawImageOptions{}
 
@@ -269,48 +284,18 @@ func (p *Player) Draw(screen *ebiten.Image) {
 	}
 
 	// Draw bullets and their trails
+	p.drawTrails(screen)
 	for _, b := range p.bullets {
 		if b.active {
-			// Draw trail
-			if len(b.trail) > 1 {
-				totalPoints := len(b.trail)
-
-				// Calculate current color for the entire trail
-				currentColor := utils.HSVToRGB(b.trailHue, 1, 1)
-
-				for i := 0; i < totalPoints-1; i++ {
-					p1 := b.trail[i]
-					p2 := b.trail[i+1]
-
-					// Calculate distance from current point to bullet (0 = at bullet, 1 = furthest)
-					distanceRatio := float64(i) / float64(totalPoints)
-
-					// Skip some segments based on distance (more gaps further from bullet)
-					if math.Sin(distanceRatio*20) > 0.3-distanceRatio {
-						continue
-					}
-
-					// Convert trail points to screen coordinates
-					x1, y1 := p.viewport.WorldToScreen(p1.x, p1.y)
-					x2, y2 := p.viewport.WorldToScreen(p2.x, p2.y)
-
-					// Apply uniform color with distance-based fade
-					fadeColor := currentColor
-					fadeColor.A = uint8(255 * (1 - distanceRatio*0.8))
-
-					// Draw line segment with fading and morphing color
-					vector.StrokeLine(screen,
-						float32(x1), float32(y1),
-						float32(x2), float32(y2),
-						2, fadeColor, false)
-				}
-			}
-
-			// Draw bullet
-			bScreenX, bScreenY := p.viewport.WorldToScreen(b.x, b.y)
+			bScreenX, bScreenY := p.viewport.WorldToScreen(p.viewport.Lerp(b.prevX, b.prevY, b.x, b.y))
 			vector.DrawFilledCircle(screen, float32(bScreenX), float32(bScreenY), 3, color.White, false)
 		}
 	}
 
-	screen.DrawImage(p.image, op)
+	// Blink during the grace period after losing a life
+	if p.invulnerable > 0 && (p.invulnerable/6)%2 == 0 {
+		op.ColorScale.ScaleAlpha(0.3)
+	}
+
+	screen.DrawImage(p.sprites.Frame(&p.anim), op)
 }
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/fabiomsouto/dfndr/internal/assets"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	shadersDir = "shaders"
+
+	crtCurvature    = 0.04
+	crtScanlines    = 0.25
+	bloomThreshold  = 0.65
+	bloomStrength   = 1.2
+	maxAberration   = 6 // Pixels the color channels split apart at full trauma
+	vignetteDarkest = 0.6
+)
+
+// PostEffect is one stage of the post-processing chain
+type PostEffect int
+
+const (
+	PostBloom PostEffect = iota
+	PostAberration
+	PostCRT
+	PostVignette
+	postEffectCount
+)
+
+var postEffectNames = [postEffectCount]string{
+	PostBloom:      "bloom",
+	PostAberration: "aberration",
+	PostCRT:        "crt",
+	PostVignette:   "vignette",
+}
+
+// postEffectKeys toggle each effect on and off
+var postEffectKeys = [postEffectCount]ebiten.Key{
+	PostBloom:      ebiten.KeyF5,
+	PostAberration: ebiten.KeyF6,
+	PostCRT:        ebiten.KeyF7,
+	PostVignette:   ebiten.KeyF8,
+}
+
+func (e PostEffect) String() string {
+	return postEffectNames[e]
+}
+
+// loadShaders compiles every Kage shader in the embedded assets, by file name
+func loadShaders() (map[string]*ebiten.Shader, error) {
+	entries, err := assets.Assets.ReadDir(shadersDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shaders in embedded assets: %w", err)
+	}
+
+	shaders := make(map[string]*ebiten.Shader, len(entries))
+	for _, entry := range entries {
+		src, err := assets.Assets.ReadFile(shadersDir + "/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read shader %s: %w", entry.Name(), err)
+		}
+		s, err := ebiten.NewShader(src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile shader %s: %w", entry.Name(), err)
+		}
+		shaders[strings.TrimSuffix(entry.Name(), ".kage")] = s
+	}
+	return shaders, nil
+}
+
+// PostProcessor runs the finished frame through a chain of full screen
+// shaders. With every effect off the game draws straight to the screen.
+type PostProcessor struct {
+	enabled [postEffectCount]bool
+	shaders map[string]*ebiten.Shader
+
+	// Offscreen images, resized along with the screen
+	frame   *ebiten.Image    // What the game draws to
+	buffers [2]*ebiten.Image // Ping-pong targets between passes
+	glow    *ebiten.Image    // Bright parts of the frame, for bloom
+	blur    [2]*ebiten.Image // Half resolution ping-pong targets for the bloom blur
+
+	trauma float64 // Camera trauma this frame, drives the aberration
+}
+
+func NewPostProcessor(library *AssetManager) *PostProcessor {
+	return &PostProcessor{shaders: library.Shaders()}
+}
+
+// Toggle turns an effect on or off
+func (pp *PostProcessor) Toggle(e PostEffect) {
+	pp.enabled[e] = !pp.enabled[e]
+}
+
+// Enabled reports whether an effect is on
+func (pp *PostProcessor) Enabled(e PostEffect) bool {
+	return pp.enabled[e]
+}
+
+func (pp *PostProcessor) active() bool {
+	for _, on := range pp.enabled {
+		if on {
+			return true
+		}
+	}
+	return false
+}
+
+// Frame returns the image the game should draw this frame to: an offscreen
+// image while any effect is on, otherwise the screen itself
+func (pp *PostProcessor) Frame(screen *ebiten.Image, trauma float64) *ebiten.Image {
+	pp.trauma = trauma
+	if !pp.active() {
+		return screen
+	}
+	size := screen.Bounds().Size()
+	if pp.frame == nil || pp.frame.Bounds().Size() != size {
+		pp.resize(size)
+	}
+	pp.frame.Clear()
+	return pp.frame
+}
+
+func (pp *PostProcessor) resize(size image.Point) {
+	pp.frame = ebiten.NewImage(size.X, size.Y)
+	pp.glow = ebiten.NewImage(size.X, size.Y)
+	for i := range pp.buffers {
+		pp.buffers[i] = ebiten.NewImage(size.X, size.Y)
+		pp.blur[i] = ebiten.NewImage(size.X/2, size.Y/2)
+	}
+}
+
+// Apply runs the frame through the enabled effects onto the screen
+func (pp *PostProcessor) Apply(screen, frame *ebiten.Image) {
+	if frame == screen {
+		return
+	}
+
+	passes := make([]func(dst, src *ebiten.Image), 0, postEffectCount)
+	if pp.enabled[PostBloom] {
+		passes = append(passes, pp.bloom)
+	}
+	if pp.enabled[PostAberration] && pp.trauma > 0 {
+		passes = append(passes, pp.aberration)
+	}
+	if pp.enabled[PostCRT] {
+		passes = append(passes, pp.crt)
+	}
+	if pp.enabled[PostVignette] {
+		passes = append(passes, pp.vignette)
+	}
+	if len(passes) == 0 {
+		screen.DrawImage(frame, nil)
+		return
+	}
+
+	src := frame
+	for i, pass := range passes {
+		dst := screen
+		if i < len(passes)-1 {
+			dst = pp.buffers[i%2]
+			dst.Clear()
+		}
+		pass(dst, src)
+		src = dst
+	}
+}
+
+// shade draws src onto dst through a shader
+func (pp *PostProcessor) shade(dst, src *ebiten.Image, shader string, uniforms map[string]any) {
+	op := &ebiten.DrawRectShaderOptions{Uniforms: uniforms}
+	op.Images[0] = src
+	size := src.Bounds().Size()
+	dst.DrawRectShader(size.X, size.Y, pp.shaders[shader], op)
+}
+
+// bloom makes bright bullets and explosions glow: the bright parts of the
+// frame are blurred at half resolution and added back on top
+func (pp *PostProcessor) bloom(dst, src *ebiten.Image) {
+	pp.glow.Clear()
+	pp.shade(pp.glow, src, "bright", map[string]any{"Threshold": bloomThreshold})
+
+	half := pp.blur[0]
+	half.Clear()
+	op := &ebiten.DrawImageOptions{Filter: ebiten.FilterLinear}
+	op.GeoM.Scale(0.5, 0.5)
+	half.DrawImage(pp.glow, op)
+
+	pp.blur[1].Clear()
+	pp.shade(pp.blur[1], pp.blur[0], "blur", map[string]any{"Direction": []float32{1, 0}})
+	pp.blur[0].Clear()
+	pp.shade(pp.blur[0], pp.blur[1], "blur", map[string]any{"Direction": []float32{0, 1}})
+
+	dst.DrawImage(src, nil)
+	op = &ebiten.DrawImageOptions{Filter: ebiten.FilterLinear, Blend: ebiten.BlendLighter}
+	op.GeoM.Scale(2, 2)
+	op.ColorScale.Scale(bloomStrength, bloomStrength, bloomStrength, bloomStrength)
+	dst.DrawImage(pp.blur[0], op)
+}
+
+// aberration splits the color channels apart while the camera is shaken
+func (pp *PostProcessor) aberration(dst, src *ebiten.Image) {
+	pp.shade(dst, src, "aberration", map[string]any{"Amount": maxAberration * pp.trauma * pp.trauma})
+}
+
+func (pp *PostProcessor) crt(dst, src *ebiten.Image) {
+	pp.shade(dst, src, "crt", map[string]any{"Curvature": crtCurvature, "Scanlines": crtScanlines})
+}
+
+func (pp *PostProcessor) vignette(dst, src *ebiten.Image) {
+	pp.shade(dst, src, "vignette", map[string]any{"Strength": vignetteDarkest})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// RenderMode is how the world looks, switched with F9. Every mode draws the
+// same simulation.
+type RenderMode int
+
+const (
+	RenderSprites RenderMode = iota
+	RenderVector
+)
+
+func (m RenderMode) String() string {
+	if m == RenderVector {
+		return "vector"
+	}
+	return "sprites"
+}
+
+// Next returns the mode that follows m when cycling through them
+func (m RenderMode) Next() RenderMode {
+	return (m + 1) % (RenderVector + 1)
+}
+
+// Renderer draws the world, the player and everything in it onto the canvas
+type Renderer interface {
+	Draw(screen *ebiten.Image, world *World)
+}
+
+func NewRenderer(mode RenderMode) Renderer {
+	if mode == RenderVector {
+		return NewVectorRenderer()
+	}
+	return &spriteRenderer{lighting: NewLighting()}
+}
+
+// spriteRenderer draws the world with its sprites, backgrounds and filled
+// terrain, lit by bullets, explosions and exhaust
+type spriteRenderer struct {
+	lighting *Lighting
+}
+
+func (r *spriteRenderer) Draw(screen *ebiten.Image, world *World) {
+	world.DrawBackground(screen)
+	world.DrawLit(r.lighting.Begin(screen))
+	r.lighting.End(screen, world)
+	world.DrawEffects(screen)
+	world.player.Draw(screen)
+	world.DrawForeground(screen)
+}
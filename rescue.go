@@ -0,0 +1,115 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/fabiomsouto/dfndr/internal/utils"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Length of the white-out when the planet explodes
+const planetFlashFrames = 90
+
+// assignLanderTargets points every idle lander at the nearest gopher that
+// nobody else is going after
+func (world *World) assignLanderTargets() {
+	for _, e := range world.enemies {
+		if !e.active || e.exploding || !e.kind.abducts || e.captive != nil {
+			continue
+		}
+		if e.target != nil && e.target.state == gopherWalking {
+			continue
+		}
+		e.target = nil
+
+		best := -1.0
+		for _, g := range world.gophers {
+			if g.state != gopherWalking || g.claimedBy(e) {
+				continue
+			}
+			d := utils.WrapDistance(e.x, e.y, g.x, g.y, WorldWidth)
+			if best < 0 || d < best {
+				best = d
+				e.target = g
+			}
+		}
+		if e.target != nil {
+			e.target.hunter = e
+		}
+	}
+}
+
+// updateGophers moves the gophers and plays out rescues and losses
+func (world *World) updateGophers() {
+	alive := 0
+	for _, g := range world.gophers {
+		ground := world.GroundY(g.x + gopherWidth/2)
+		switch g.state {
+		case gopherWalking:
+			g.walk(ground)
+		case gopherAbducted:
+			g.hangFrom(g.abductor)
+		case gopherFalling:
+			g.fall()
+			if world.shipTouches(g) {
+				g.state = gopherCarried
+				g.ride(world.player)
+				world.particles.Burst("pickup", g.x+gopherWidth/2, g.y+gopherHeight/2, 0)
+			} else if g.y+gopherHeight >= ground {
+				if ground-gopherHeight-g.fallFrom < safeFallHeight {
+					g.land(ground)
+					world.addScore(landingBonus)
+				} else {
+					g.Kill()
+				}
+			}
+		case gopherCarried:
+			g.ride(world.player)
+			if g.y+gopherHeight >= ground {
+				g.land(ground)
+				world.addScore(rescueBonus)
+				world.particles.Burst("pickup", g.x+gopherWidth/2, g.y+gopherHeight/2, 0)
+			}
+		}
+		g.anim.Update()
+
+		if g.Alive() {
+			alive++
+		}
+	}
+
+	if alive == 0 && !world.planetDestroyed {
+		world.destroyPlanet()
+	}
+}
+
+// shipTouches reports whether the ship overlaps a gopher, across the world seam
+func (world *World) shipTouches(g *Gopher) bool {
+	p := world.player
+	dx, dy := utils.WrapDirection(p.x, p.y, g.x, g.y, WorldWidth)
+	return dx+gopherWidth > 0 && dx < shipWidth &&
+		dy+gopherHeight > 0 && dy < shipHeight
+}
+
+// destroyPlanet blows up the planet once every gopher is gone. Without a
+// planet there is nothing left to abduct, so every enemy turns mutant.
+func (world *World) destroyPlanet() {
+	world.planetDestroyed = true
+	world.planetFlash = planetFlashFrames
+	world.viewport.AddTrauma(planetTrauma)
+	world.viewport.HitStop(planetStop)
+	for _, e := range world.enemies {
+		if e.active && !e.exploding {
+			e.setKind(mutantKind)
+		}
+	}
+}
+
+func (world *World) drawPlanetFlash(screen *ebiten.Image) {
+	if world.planetFlash <= 0 {
+		return
+	}
+	alpha := uint8(255 * world.planetFlash / planetFlashFrames)
+	vector.DrawFilledRect(screen, 0, 0, float32(world.viewport.width), float32(world.viewport.height), color.RGBA{R: alpha, G: alpha, B: alpha, A: alpha}, false)
+}
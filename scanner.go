@@ -0,0 +1,185 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/fabiomsouto/dfndr/internal/terrain"
+	"github.com/fabiomsouto/dfndr/internal/utils"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	scannerHeight   = 80
+	scannerMaxWidth = 1200 // Wide screens don't get a wider scanner past this
+	scannerTop      = 4
+	blipSize        = 3
+
+	// Blips past this many are left off, so a crowded world can't outgrow
+	// the batch. Enough for every hazard, gopher and a few waves' worth of
+	// enemies.
+	scannerMaxBlips = 256
+	// Quads drawn besides the terrain and blips: background, bracket,
+	// border and the player
+	scannerChrome = 12
+)
+
+var (
+	scannerBackground = color.RGBA{R: 0, G: 0, B: 0, A: 200}
+	scannerBorder     = color.RGBA{R: 60, G: 60, B: 160, A: 255}
+	scannerTerrain    = color.RGBA{R: 160, G: 90, B: 30, A: 255}
+	scannerBracket    = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	scannerGopher     = color.RGBA{R: 125, G: 213, B: 234, A: 255}
+	scannerPickup     = color.RGBA{R: 255, G: 230, B: 80, A: 255}
+	scannerPlayer     = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	scannerAsteroid   = color.RGBA{R: 150, G: 150, B: 150, A: 255}
+	scannerWell       = color.RGBA{R: 170, G: 90, B: 255, A: 255}
+)
+
+// Scanner is the long-range radar at the top of the screen. It shows the
+// whole world squeezed into a small panel, centered on the camera, so the
+// seam never shows up as an edge.
+type Scanner struct {
+	x, y          float64 // Top-left corner on screen
+	width, height float64
+
+	terrain *terrain.Terrain
+	heights []float64 // Terrain height for each scanner column, sampled once
+	blips   int       // Blips added this frame
+
+	worldHeight float64 // Height of the world shown, set every frame
+
+	// Reused every frame so drawing the scanner doesn't allocate
+	vertices []ebiten.Vertex
+	indices  []uint16
+}
+
+func NewScanner() *Scanner {
+	return &Scanner{
+		y:      scannerTop,
+		height: scannerHeight,
+	}
+}
+
+// layout sizes the scanner to half the screen width, centered at the top
+func (s *Scanner) layout(screenWidth int) {
+	width := min(screenWidth/2, scannerMaxWidth)
+	s.x = float64(screenWidth-width) / 2
+	if width == len(s.heights) {
+		return
+	}
+	s.width = float64(width)
+	s.heights = make([]float64, width)
+	s.terrain = nil // Sample again at the new resolution
+	quads := width + scannerMaxBlips + scannerChrome
+	s.vertices = make([]ebiten.Vertex, 0, 4*quads)
+	s.indices = make([]uint16, 0, 6*quads)
+}
+
+// sampleTerrain caches the terrain profile at scanner resolution
+func (s *Scanner) sampleTerrain(t *terrain.Terrain) {
+	s.terrain = t
+	for i := range s.heights {
+		s.heights[i] = t.HeightAt(float64(i) * WorldWidth / s.width)
+	}
+}
+
+// project maps a world position onto the scanner, relative to centerX
+func (s *Scanner) project(worldX, worldY, centerX float64) (float32, float32) {
+	dx := utils.WrapDelta(centerX, worldX, WorldWidth)
+	x := s.x + (dx/WorldWidth+0.5)*s.width
+	y := s.y + worldY/s.worldHeight*s.height
+	return float32(x), float32(y)
+}
+
+// blip adds a marker centered on a world position, unless the scanner
+// already shows scannerMaxBlips of them
+func (s *Scanner) blip(worldX, worldY, centerX float64, size float32, clr color.RGBA) {
+	if s.blips == scannerMaxBlips {
+		return
+	}
+	s.blips++
+	s.mark(worldX, worldY, centerX, size, clr)
+}
+
+// mark adds a marker centered on a world position
+func (s *Scanner) mark(worldX, worldY, centerX float64, size float32, clr color.RGBA) {
+	x, y := s.project(worldX, worldY, centerX)
+	half := size / 2
+	s.rect(x-half, y-half, size, size, clr)
+}
+
+func (s *Scanner) rect(x, y, w, h float32, clr color.RGBA) {
+	s.vertices, s.indices = appendQuad(s.vertices, s.indices, x, y, x+w, y, x+w, y+h, x, y+h, clr)
+}
+
+func (s *Scanner) Draw(screen *ebiten.Image, world *World) {
+	s.layout(screen.Bounds().Dx())
+	if s.terrain != world.ground.terrain {
+		s.sampleTerrain(world.ground.terrain)
+	}
+
+	s.vertices = s.vertices[:0]
+	s.indices = s.indices[:0]
+	s.blips = 0
+	s.worldHeight = world.sector.height
+
+	left, top := float32(s.x), float32(s.y)
+	width, height := float32(s.width), float32(s.height)
+	s.rect(left, top, width, height, scannerBackground)
+
+	// Center on the middle of the camera
+	v := world.viewport
+	centerX := utils.Wrap(v.x+v.width/2, WorldWidth)
+
+	// Terrain, one dot per column, rotated so the camera sits in the middle
+	if !world.planetDestroyed {
+		shift := int(centerX/WorldWidth*s.width) - len(s.heights)/2
+		for col := range s.heights {
+			h := s.heights[((col+shift)%len(s.heights)+len(s.heights))%len(s.heights)]
+			y := top + float32((s.worldHeight-h)/s.worldHeight*s.height)
+			s.rect(left+float32(col), y, 1, 1, scannerTerrain)
+		}
+	}
+
+	for _, w := range world.wells {
+		s.blip(w.x, w.y, centerX, blipSize+2, scannerWell)
+	}
+	for _, a := range world.asteroids {
+		s.blip(a.x, a.y, centerX, 2, scannerAsteroid)
+	}
+	for _, g := range world.gophers {
+		switch {
+		case g.state == gopherFalling:
+			// Falling gophers are there for the catching
+			s.blip(g.x+gopherWidth/2, g.y+gopherHeight/2, centerX, blipSize, scannerPickup)
+		case g.Alive():
+			s.blip(g.x+gopherWidth/2, g.y+gopherHeight/2, centerX, 2, scannerGopher)
+		}
+	}
+	for _, e := range world.enemies {
+		if e.active && !e.exploding && !e.waiting() {
+			s.blip(e.x+enemyWidth/2, e.y+enemyHeight/2, centerX, blipSize, e.kind.scannerColor)
+		}
+	}
+	p := world.player
+	s.mark(p.x+shipWidth/2, p.y+shipHeight/2, centerX, blipSize+1, scannerPlayer)
+
+	// Bracket around the part of the world the camera shows
+	bx0, _ := s.project(v.x, 0, centerX)
+	bx1 := bx0 + float32(v.width/WorldWidth*s.width)
+	const tick = 6
+	s.rect(bx0, top, 1, height, scannerBracket)
+	s.rect(bx1, top, 1, height, scannerBracket)
+	s.rect(bx0, top, tick, 1, scannerBracket)
+	s.rect(bx0, top+height-1, tick, 1, scannerBracket)
+	s.rect(bx1-tick+1, top, tick, 1, scannerBracket)
+	s.rect(bx1-tick+1, top+height-1, tick, 1, scannerBracket)
+
+	// Border
+	s.rect(left, top, width, 1, scannerBorder)
+	s.rect(left, top+height-1, width, 1, scannerBorder)
+	s.rect(left, top, 1, height, scannerBorder)
+	s.rect(left+width-1, top, 1, height, scannerBorder)
+
+	screen.DrawTriangles(s.vertices, s.indices, whitePixel, nil)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"image/color"
+	"math/rand"
+
+	"github.com/fabiomsouto/dfndr/internal/terrain"
+	"github.com/fabiomsouto/dfndr/internal/utils"
+)
+
+const (
+	paletteSize = 6
+	// Every level is this much darker than the last, down to minAmbient
+	ambientFalloff = 0.04
+	minAmbient     = 0.25
+	// Waves planned per sector, before the plan starts over
+	baseWaves = 4
+)
+
+// Biome is the flavor of a sector: its colors, the lay of the land and how
+// hostile it is
+type Biome struct {
+	name string
+
+	groundHue float64 // Hue of the planet surface, in degrees
+	skyHue    float64 // Hue the stars, nebulae and distant mountains revolve around
+	hueSpread float64 // How far the sky palette strays from skyHue
+
+	roughness float64 // Ground roughness, see terrain.Params
+	relief    float64 // Scales the height of the ground and mountains
+	ambient   float64 // Light level away from bullets and explosions, 0-1
+
+	asteroids float64 // Scales the number of asteroids
+	wells     float64 // Scales the number of gravity wells
+	tempo     float64 // Scales how quickly attack runs follow each other, higher is faster
+}
+
+var biomes = []*Biome{
+	{
+		name:      "legacy codebase",
+		groundHue: 30, skyHue: 40, hueSpread: 25,
+		roughness: 0.65, relief: 1.2, ambient: 0.55,
+		asteroids: 1.5, wells: 0.5, tempo: 0.8,
+	},
+	{
+		name:      "microservice nebula",
+		groundHue: 190, skyHue: 280, hueSpread: 60,
+		roughness: 0.4, relief: 0.7, ambient: 0.7,
+		asteroids: 0.6, wells: 1.5, tempo: 1.1,
+	},
+	{
+		name:      "monorepo plains",
+		groundHue: 100, skyHue: 210, hueSpread: 30,
+		roughness: 0.3, relief: 0.5, ambient: 0.9,
+		asteroids: 1, wells: 1, tempo: 1,
+	},
+	{
+		name:      "dependency hell",
+		groundHue: 0, skyHue: 340, hueSpread: 20,
+		roughness: 0.7, relief: 1.4, ambient: 0.4,
+		asteroids: 1.2, wells: 2, tempo: 1.3,
+	},
+}
+
+// plannedWave is one scripted attack run in a sector's wave plan
+type plannedWave struct {
+	formation *Formation
+	delay     int // Frames to wait before launching it
+}
+
+// Sector is everything that makes a level look and play the way it does,
+// generated from a seed and the level number. The same seed and level
+// always yield the same sector.
+type Sector struct {
+	seed   int64
+	level  int
+	height float64 // How tall the world is, the ground sits at the bottom
+	biome  *Biome
+
+	ground, mountains *terrain.Terrain
+	groundFill        color.RGBA
+	groundOutline     color.RGBA
+	mountainFill      color.RGBA
+	mountainOutline   color.RGBA
+	palette           []color.RGBA // Colors for the background layers
+	ambient           float64      // Light level away from bullets and explosions, 0-1
+	asteroids         []*Asteroid
+	wells             []*GravityWell
+	gophers           []float64 // Starting x of each gopher
+	waves             []plannedWave
+	waveInterval      int // Frames between attack runs, sped up by the biome's tempo and the level
+}
+
+func GenerateSector(seed int64, level int, height float64, formations []*Formation) *Sector {
+	// Mix the level in so every level of a run looks different
+	rng := rand.New(rand.NewSource(seed ^ int64(level)*0x5851f42d4c957f2d))
+	biome := biomes[rng.Intn(len(biomes))]
+	s := &Sector{
+		seed:    rng.Int63(),
+		level:   level,
+		height:  height,
+		biome:   biome,
+		ambient: max(minAmbient, biome.ambient-ambientFalloff*float64(level-1)),
+	}
+
+	ground := groundParams
+	ground.Roughness = biome.roughness
+	ground.MaxHeight = ground.MinHeight + (ground.MaxHeight-ground.MinHeight)*biome.relief
+	mountains := mountainParams
+	mountains.MaxHeight = mountains.MinHeight + (mountains.MaxHeight-mountains.MinHeight)*biome.relief
+	s.ground = terrain.Generate(rng.Int63(), ground)
+	s.mountains = terrain.Generate(rng.Int63(), mountains)
+
+	s.groundFill = utils.HSVToRGB(biome.groundHue, 0.7, 0.27)
+	s.groundOutline = utils.HSVToRGB(biome.groundHue, 0.8, 0.8)
+	s.mountainFill = utils.HSVToRGB(biome.skyHue, 0.6, 0.16)
+	s.mountainOutline = utils.HSVToRGB(biome.skyHue, 0.6, 0.47)
+	s.palette = make([]color.RGBA, paletteSize)
+	for i := range s.palette {
+		hue := biome.skyHue + (rng.Float64()*2-1)*biome.hueSpread + 360
+		s.palette[i] = utils.HSVToRGB(hue, 0.3+rng.Float64()*0.4, 1)
+	}
+
+	s.placeHazards(rng)
+	s.gophers = make([]float64, Gophers)
+	for i := range s.gophers {
+		s.gophers[i] = rng.Float64() * WorldWidth
+	}
+	s.planWaves(rng, formations)
+	return s
+}
+
+// groundY returns the world y coordinate of the sector's surface at x
+func (s *Sector) groundY(x float64) float64 {
+	return s.height - s.ground.HeightAt(x)
+}
+
+// placeHazards scatters the asteroids and gravity wells over the sector
+func (s *Sector) placeHazards(rng *rand.Rand) {
+	plan := hazardPlans[min(s.level-1, len(hazardPlans)-1)]
+	asteroids := int(float64(plan.asteroids)*s.biome.asteroids + 0.5)
+	wells := int(float64(plan.wells)*s.biome.wells + 0.5)
+
+	s.asteroids = make([]*Asteroid, 0, asteroids*4)
+	for range asteroids {
+		x := rng.Float64() * WorldWidth
+		y := rng.Float64() * (s.groundY(x) - 200)
+		s.asteroids = append(s.asteroids, NewAsteroid(x, y, 30+rng.Float64()*25, rng))
+	}
+	s.wells = make([]*GravityWell, wells)
+	for i := range s.wells {
+		x := rng.Float64() * WorldWidth
+		s.wells[i] = &GravityWell{
+			x:        x,
+			y:        200 + rng.Float64()*(s.groundY(x)-500),
+			radius:   450,
+			core:     35,
+			strength: 0.6,
+		}
+	}
+}
+
+// planWaves picks the order and timing of the scripted attack runs. Higher
+// levels get more of them, closer together.
+func (s *Sector) planWaves(rng *rand.Rand, formations []*Formation) {
+	if len(formations) == 0 {
+		return
+	}
+	interval := float64(waveInterval) / (s.biome.tempo * (1 + 0.1*float64(s.level-1)))
+	s.waveInterval = int(interval)
+	s.waves = make([]plannedWave, baseWaves+s.level)
+	for i := range s.waves {
+		delay := interval * (0.75 + rng.Float64()*0.5)
+		if i == 0 {
+			delay = firstWaveDelay
+		}
+		s.waves[i] = plannedWave{
+			formation: formations[rng.Intn(len(formations))],
+			delay:     int(delay),
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io/fs"
+
+	"github.com/fabiomsouto/dfndr/internal/anim"
+	"github.com/fabiomsouto/dfndr/internal/theme"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// SpriteSheet is a sprite sheet image cut into frames, together with the
+// animation clips defined for it in internal/assets/animations
+type SpriteSheet struct {
+	*anim.Sheet
+	frames []*ebiten.Image
+}
+
+// decodeSpriteSheet reads an animation definition and decodes its sheet
+// image, which lives in the same theme pack
+func decodeSpriteSheet(file theme.File) (*SpriteSheet, image.Image, error) {
+	data, err := file.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read animations %s: %w", file.Path, err)
+	}
+	sheet, err := anim.Parse(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse animations %s: %w", file.Path, err)
+	}
+
+	data, err = fs.ReadFile(file.FS, sheet.Image)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", sheet.Image, err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode %s: %w", sheet.Image, err)
+	}
+	return &SpriteSheet{Sheet: sheet}, img, nil
+}
+
+// cut slices the sheet, packed into the atlas at r, into frames, left to right
+func (s *SpriteSheet) cut(atlas *ebiten.Image, r image.Rectangle) {
+	count := r.Dx() / s.FrameWidth
+	s.frames = make([]*ebiten.Image, count)
+	for i := range s.frames {
+		x := r.Min.X + i*s.FrameWidth
+		s.frames[i] = atlas.SubImage(image.Rect(x, r.Min.Y, x+s.FrameWidth, r.Min.Y+s.FrameHeight)).(*ebiten.Image)
+	}
+}
+
+// Frame returns the image for the animator's current frame
+func (s *SpriteSheet) Frame(a *anim.Animator) *ebiten.Image {
+	i := a.Frame()
+	if i < 0 || i >= len(s.frames) {
+		return s.frames[0]
+	}
+	return s.frames[i]
+}
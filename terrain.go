@@ -0,0 +1,86 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/fabiomsouto/dfndr/internal/terrain"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	// Screen distance between terrain columns when drawing
+	terrainDrawStep = 8
+	terrainSamples  = 512
+)
+
+var (
+	// The planet surface, which the ship and enemies collide with
+	groundParams = terrain.Params{
+		Width:     WorldWidth,
+		Samples:   terrainSamples,
+		MinHeight: 30,
+		MaxHeight: 170,
+		Roughness: 0.55,
+	}
+	// Distant mountains behind the surface, purely decorative
+	mountainParams = terrain.Params{
+		Width:     WorldWidth,
+		Samples:   terrainSamples / 2,
+		MinHeight: 80,
+		MaxHeight: 320,
+		Roughness: 0.45,
+	}
+)
+
+// TerrainLayer draws a terrain profile as a filled mountain line
+type TerrainLayer struct {
+	terrain  *terrain.Terrain
+	parallax float64 // How much the layer moves relative to the camera (0.0-1.0)
+	fill     color.RGBA
+	outline  color.RGBA
+
+	// Reused every frame to batch the fill into a single draw call
+	vertices []ebiten.Vertex
+	indices  []uint16
+}
+
+func NewTerrainLayer(t *terrain.Terrain, parallax float64, fill, outline color.RGBA) *TerrainLayer {
+	return &TerrainLayer{
+		terrain:  t,
+		parallax: parallax,
+		fill:     fill,
+		outline:  outline,
+	}
+}
+
+// Draw fills the layer from its surface down to the bottom of the world
+func (l *TerrainLayer) Draw(screen *ebiten.Image, viewport *Viewport) {
+	offsetX := viewport.ParallaxX(l.parallax)
+	offsetY := viewport.ParallaxY(l.parallax)
+	bottom := float32(viewport.worldHeight - offsetY)
+
+	l.vertices = l.vertices[:0]
+	l.indices = l.indices[:0]
+	var outline vector.Path
+
+	prevX := float32(0)
+	prevY := float32(viewport.worldHeight - l.terrain.HeightAt(offsetX) - offsetY)
+	outline.MoveTo(prevX, prevY)
+	for sx := terrainDrawStep; sx <= int(viewport.width)+terrainDrawStep; sx += terrainDrawStep {
+		x := float32(sx)
+		y := float32(viewport.worldHeight - l.terrain.HeightAt(offsetX+float64(sx)) - offsetY)
+		l.vertices, l.indices = appendQuad(l.vertices, l.indices, prevX, prevY, x, y, x, bottom, prevX, bottom, l.fill)
+		outline.LineTo(x, y)
+		prevX, prevY = x, y
+	}
+	screen.DrawTriangles(l.vertices, l.indices, whitePixel, nil)
+
+	l.vertices, l.indices = outline.AppendVerticesAndIndicesForStroke(l.vertices[:0], l.indices[:0], &vector.StrokeOptions{Width: 2})
+	r, g, b, a := float32(l.outline.R)/255, float32(l.outline.G)/255, float32(l.outline.B)/255, float32(l.outline.A)/255
+	for i := range l.vertices {
+		l.vertices[i].SrcX, l.vertices[i].SrcY = 1, 1
+		l.vertices[i].ColorR, l.vertices[i].ColorG, l.vertices[i].ColorB, l.vertices[i].ColorA = r, g, b, a
+	}
+	screen.DrawTriangles(l.vertices, l.indices, whitePixel, &ebiten.DrawTrianglesOptions{AntiAlias: true})
+}
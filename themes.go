@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"os"
+	"path"
+
+	"github.com/fabiomsouto/dfndr/internal/assets"
+	"github.com/fabiomsouto/dfndr/internal/theme"
+)
+
+const (
+	// Built-in theme packs, one per directory, besides the game's own at
+	// the root of the assets
+	themesDir = "themes"
+	hudFont   = "hud"
+)
+
+// themeColor is a palette color, given as [r, g, b, a] in palette files
+type themeColor struct {
+	color.RGBA
+}
+
+func (c *themeColor) UnmarshalJSON(data []byte) error {
+	var v [4]uint8
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	c.RGBA = color.RGBA{R: v[0], G: v[1], B: v[2], A: v[3]}
+	return nil
+}
+
+// or returns the color, or fallback if the palette leaves it transparent
+func (c themeColor) or(fallback color.RGBA) color.RGBA {
+	if c.A == 0 {
+		return fallback
+	}
+	return c.RGBA
+}
+
+// Palette holds the colors a theme can change. A theme's palette is laid
+// over the game's own, so it only needs the colors it changes: anything it
+// leaves out, such as shieldLow and barBack in the arcade pack, keeps the
+// game's color.
+type Palette struct {
+	// HUD
+	Text       themeColor `json:"text"`
+	Dim        themeColor `json:"dim"`
+	Multiplier themeColor `json:"multiplier"`
+	Shield     themeColor `json:"shield"`
+	ShieldLow  themeColor `json:"shieldLow"`
+	BarBack    themeColor `json:"barBack"`
+
+	// Hazards
+	Asteroid        themeColor `json:"asteroid"`
+	AsteroidOutline themeColor `json:"asteroidOutline"`
+	Well            themeColor `json:"well"`
+
+	// Line colors in the vector render mode, where sprites have none
+	Ship   themeColor `json:"ship"`
+	Gopher themeColor `json:"gopher"`
+
+	// Bullet trail style, one of trailStyles. Left out, bullets leave rainbows.
+	Trail string `json:"trail"`
+
+	// Terrain colors are optional. Left out, every sector picks its own.
+	Ground           themeColor `json:"ground"`
+	GroundOutline    themeColor `json:"groundOutline"`
+	Mountains        themeColor `json:"mountains"`
+	MountainsOutline themeColor `json:"mountainsOutline"`
+}
+
+// loadPalette reads a theme's palette files, each one changing only the
+// colors it gives
+func loadPalette(files []theme.File) (*Palette, error) {
+	p := &Palette{}
+	for _, f := range files {
+		data, err := f.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read palette %s: %w", f.Path, err)
+		}
+		if err := json.Unmarshal(data, p); err != nil {
+			return nil, fmt.Errorf("failed to parse palette %s: %w", f.Path, err)
+		}
+	}
+	if _, ok := trailStyles[p.Trail]; p.Trail != "" && !ok {
+		return nil, fmt.Errorf("palette picks unknown trail style %q", p.Trail)
+	}
+	return p, nil
+}
+
+// trailStyle returns the bullet trail style the palette picks
+func (p *Palette) trailStyle() *TrailStyle {
+	if style, ok := trailStyles[p.Trail]; ok {
+		return style
+	}
+	return &rainbowTrail
+}
+
+// loadTheme finds a theme pack, either built in or in a directory on disk,
+// and lays it over the game's own. An empty name is the game's own theme.
+func loadTheme(name string) (*theme.Theme, error) {
+	base, err := theme.Load(assets.Assets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the default theme: %w", err)
+	}
+	if name == "" {
+		return base, nil
+	}
+
+	var fsys fs.FS = os.DirFS(name)
+	if builtin := path.Join(themesDir, name); fs.ValidPath(builtin) {
+		if _, err := fs.Stat(assets.Assets, builtin); err == nil {
+			fsys, _ = fs.Sub(assets.Assets, builtin)
+		}
+	}
+	pack, err := theme.Load(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load theme %s: %w", name, err)
+	}
+	return pack.Over(base), nil
+}
+
+// themeChoices lists the themes to cycle through: the game's own, the
+// built-in packs and, if it isn't one of those, the selected pack. It
+// also returns where the selected one is in the list.
+func themeChoices(selected string) ([]string, int) {
+	names := []string{""}
+	entries, _ := fs.ReadDir(assets.Assets, themesDir)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	for i, name := range names {
+		if name == selected {
+			return names, i
+		}
+	}
+	return append(names, selected), len(names)
+}
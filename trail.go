@@ -0,0 +1,146 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/fabiomsouto/dfndr/internal/utils"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	trailPoints  = 32  // Points kept per bullet trail
+	trailSpacing = 5   // Distance a bullet travels before it lays another point
+	trailCull    = 400 // Points further than this behind the bullet are dropped, whichever way it flies
+)
+
+// TrailStyle is how bullet trails look. The ribbon tapers from the bullet
+// to the tail, and can cycle through colors and break up into dashes.
+type TrailStyle struct {
+	width      float64 // Width of the ribbon at the bullet
+	fade       float64 // Fraction of its opacity the ribbon loses towards the tail
+	saturation float64
+	hueStep    float64 // Degrees the color cycles every time a point is laid, 0 keeps it still
+	hueSpread  float64 // Degrees the color shifts from the bullet to the tail
+
+	// The ribbon breaks up where sin(t*gapFrequency) > gapBias-t, t being
+	// 0 at the bullet and 1 at the tail, so gaps grow towards the tail. A
+	// gapFrequency of 0 draws it solid.
+	gapFrequency float64
+	gapBias      float64
+}
+
+var (
+	// rainbowTrail cycles through every color and frays towards its tail
+	rainbowTrail = TrailStyle{width: 4, fade: 0.8, saturation: 1, hueStep: 2, gapFrequency: 20, gapBias: 0.3}
+	// plasmaTrail is a solid beam that cools down along its length
+	plasmaTrail = TrailStyle{width: 6, fade: 1, saturation: 0.7, hueSpread: 60}
+
+	// trailStyles are the styles a theme's palette can pick by name
+	trailStyles = map[string]*TrailStyle{
+		"rainbow": &rainbowTrail,
+		"plasma":  &plasmaTrail,
+	}
+)
+
+// color returns the color of a ribbon of the given hue, t of the way from
+// the bullet to the tail
+func (s *TrailStyle) color(hue, t float64) color.RGBA {
+	c := utils.HSVToRGB(math.Mod(hue+s.hueSpread*t, 360), s.saturation, 1)
+	c.A = uint8(255 * (1 - t*s.fade))
+	return c
+}
+
+type TrailPoint struct {
+	x, y float64
+}
+
+// updateTrail lays another point behind the bullet, and drops those that
+// fell too far behind it
+func (b *Bullet) updateTrail(style *TrailStyle) {
+	t := b.trail
+	if t.Len() == 0 {
+		t.Push(TrailPoint{x: b.x, y: b.y})
+	} else if last := t.At(t.Len() - 1); utils.WrapDistance(last.x, last.y, b.x, b.y, WorldWidth) >= trailSpacing {
+		b.trailHue = math.Mod(b.trailHue+style.hueStep, 360)
+		t.Push(TrailPoint{x: b.x, y: b.y})
+	}
+	for t.Len() > 0 {
+		oldest := t.At(0)
+		if utils.WrapDistance(oldest.x, oldest.y, b.x, b.y, WorldWidth) <= trailCull {
+			break
+		}
+		t.DropOldest()
+	}
+}
+
+// trailHead returns where the bullet is drawn between ticks, and how many
+// of its trail points lie behind that. Points laid this tick may lie ahead
+// of it, and are left out so the trail doesn't run past the bullet.
+func (b *Bullet) trailHead(v *Viewport) (x, y float64, behind int) {
+	x, y = v.Lerp(b.prevX, b.prevY, b.x, b.y)
+	ahead := utils.WrapDistance(x, y, b.x, b.y, WorldWidth)
+	behind = b.trail.Len()
+	for behind > 0 {
+		pt := b.trail.At(behind - 1)
+		if utils.WrapDistance(pt.x, pt.y, b.x, b.y, WorldWidth) > ahead {
+			break
+		}
+		behind--
+	}
+	return x, y, behind
+}
+
+// drawTrails draws the trails of every bullet in flight as tapered ribbons,
+// in a single batch
+func (p *Player) drawTrails(screen *ebiten.Image) {
+	v, style := p.viewport, p.trailStyle
+	p.trailVertices = p.trailVertices[:0]
+	p.trailIndices = p.trailIndices[:0]
+	for _, b := range p.bullets {
+		if !b.active || b.trail.Len() == 0 {
+			continue
+		}
+		// The ribbon runs from the oldest point to where the bullet is drawn
+		headX, headY, behind := b.trailHead(v)
+		if behind == 0 {
+			continue
+		}
+		n := behind + 1
+		point := func(i int) (float64, float64) {
+			if i == n-1 {
+				return v.WorldToScreen(headX, headY)
+			}
+			pt := b.trail.At(i)
+			return v.WorldToScreen(pt.x, pt.y)
+		}
+
+		x0, y0 := point(0)
+		for i := 1; i < n; i++ {
+			x1, y1 := point(i)
+			t0 := float64(n-i) / float64(n-1) // 0 at the bullet, 1 at the tail
+			t1 := float64(n-1-i) / float64(n-1)
+			if style.gapFrequency > 0 && math.Sin(t0*style.gapFrequency) > style.gapBias-t0 {
+				x0, y0 = x1, y1
+				continue
+			}
+			l := math.Hypot(x1-x0, y1-y0)
+			if l > 0 {
+				// Across the ribbon, half its width at each end
+				nx, ny := -(y1-y0)/l, (x1-x0)/l
+				w0, w1 := style.width*(1-t0)/2, style.width*(1-t1)/2
+				c0, c1 := style.color(b.trailHue, t0), style.color(b.trailHue, t1)
+				base := uint16(len(p.trailVertices))
+				p.trailVertices = append(p.trailVertices,
+					solidVertex(float32(x0+nx*w0), float32(y0+ny*w0), c0),
+					solidVertex(float32(x1+nx*w1), float32(y1+ny*w1), c1),
+					solidVertex(float32(x1-nx*w1), float32(y1-ny*w1), c1),
+					solidVertex(float32(x0-nx*w0), float32(y0-ny*w0), c0),
+				)
+				p.trailIndices = append(p.trailIndices, base, base+1, base+2, base, base+2, base+3)
+			}
+			x0, y0 = x1, y1
+		}
+	}
+	screen.DrawTriangles(p.trailVertices, p.trailIndices, whitePixel, &ebiten.DrawTrianglesOptions{AntiAlias: true})
+}
@@ -1,86 +1,120 @@
 package main
 
-import "math"
-
-const (
-	// Horizontal deadzone - how far from center the player can move before scrolling starts
-	deadzoneX = 200.0
-	// Vertical deadzone - how far from center the player can move before scrolling starts
-	deadzoneY = 150.0
+import (
+	"github.com/fabiomsouto/dfndr/internal/utils"
 )
 
 type Viewport struct {
 	x, y          float64 // top-left corner of viewport in world coordinates
 	width, height float64
 	worldWidth    float64
+	worldHeight   float64
+	scrollX       float64 // total horizontal distance scrolled, never wrapped
+
+	// Camera effects, see effects.go
+	baseWidth, baseHeight float64 // Size of the viewport when not zoomed
+	zoom                  float64
+	trauma                float64 // Drives the screen shake, 0-1
+	shakeX, shakeY        float64
+	shakeAngle            float64
+	hitStop               int // Frames left of the current freeze
+	ticks                 int
+	effects               EffectsLevel
+
+	// Where the camera was at the previous simulation tick, and how far
+	// between that tick and the latest one drawing happens
+	prevX, prevY, prevScrollX float64
+	alpha                     float64
 }
 
-func NewViewport(width, height, worldWidth float64) *Viewport {
+func NewViewport(width, height, worldWidth, worldHeight float64) *Viewport {
+	// Start at the bottom of the world, where the planet surface is
 	return &Viewport{
-		x:          0,
-		y:          0,
-		width:      width,
-		height:     height,
-		worldWidth: worldWidth,
+		x:           0,
+		y:           worldHeight - height,
+		width:       width,
+		height:      height,
+		worldWidth:  worldWidth,
+		worldHeight: worldHeight,
+		baseWidth:   width,
+		baseHeight:  height,
+		zoom:        1,
+		prevY:       worldHeight - height,
+		alpha:       1,
 	}
 }
 
-// Follow updates the viewport position to follow a target
-func (v *Viewport) Follow(targetX, targetY float64) {
-	// Get the center of the viewport
-	viewportCenterX := v.x + v.width/2
-	viewportCenterY := v.y + v.height/2
+// Resize changes the size of the screen the viewport is shown on, keeping
+// the camera centered where it was
+func (v *Viewport) Resize(width, height float64) {
+	v.baseWidth, v.baseHeight = width, height
+	v.setZoom(v.zoom)
+}
 
-	// Calculate how far the target is from the viewport center
-	deltaX := targetX - viewportCenterX
-	deltaY := targetY - viewportCenterY
+// Move scrolls the viewport, wrapping around the world horizontally and
+// staying inside it vertically
+func (v *Viewport) Move(dx, dy float64) {
+	v.x = utils.Wrap(v.x+dx, v.worldWidth)
+	v.scrollX += dx
+	v.y = max(0, min(v.y+dy, v.worldHeight-v.height))
+}
 
-	// Only move the viewport if the target is outside the deadzone
-	if math.Abs(deltaX) > deadzoneX {
-		// Move the viewport, keeping the target at the edge of the deadzone
-		if deltaX > 0 {
-			v.x += deltaX - deadzoneX
-		} else {
-			v.x += deltaX + deadzoneX
-		}
-	}
+// Snapshot remembers where the camera is before a simulation tick moves it
+func (v *Viewport) Snapshot() {
+	v.prevX, v.prevY, v.prevScrollX = v.x, v.y, v.scrollX
+}
 
-	if math.Abs(deltaY) > deadzoneY {
-		// Move the viewport, keeping the target at the edge of the deadzone
-		if deltaY > 0 {
-			v.y += deltaY - deadzoneY
-		} else {
-			v.y += deltaY + deadzoneY
-		}
-	}
+// SetAlpha sets how far between the previous and the latest simulation tick
+// the next frame is drawn, from 0 to 1. The simulation itself always sees 1.
+func (v *Viewport) SetAlpha(alpha float64) {
+	v.alpha = alpha
+}
 
-	// Keep the viewport within the world bounds
-	if v.x < 0 {
-		v.x = 0
-	}
-	if v.x > v.worldWidth-v.width {
-		v.x = v.worldWidth - v.width
-	}
+// Lerp returns the position of something that moved from prevX, prevY to
+// x, y in the last tick, as of the current alpha. It takes the short way
+// across the world seam.
+func (v *Viewport) Lerp(prevX, prevY, x, y float64) (float64, float64) {
+	return utils.Wrap(prevX+utils.WrapDelta(prevX, x, v.worldWidth)*v.alpha, v.worldWidth), prevY + (y-prevY)*v.alpha
+}
 
-	// Keep viewport within vertical bounds
-	if v.y < 0 {
-		v.y = 0
-	}
-	if v.y > ScreenHeight-v.height {
-		v.y = ScreenHeight - v.height
-	}
+// camera returns the top-left corner of the viewport as of the current alpha
+func (v *Viewport) camera() (float64, float64) {
+	return v.Lerp(v.prevX, v.prevY, v.x, v.y)
 }
 
-// WorldToScreen converts world coordinates to screen coordinates
+// WorldToScreen converts world coordinates to screen coordinates. The world
+// wraps horizontally, so it picks the copy of the point nearest to the
+// center of the screen.
 func (v *Viewport) WorldToScreen(worldX, worldY float64) (float64, float64) {
-	screenX := worldX - v.x
-	screenY := worldY - v.y
+	camX, camY := v.camera()
+	screenX := utils.WrapDelta(camX+v.width/2, worldX, v.worldWidth) + v.width/2
+	screenY := worldY - camY
 	return screenX, screenY
 }
 
 // ScreenToWorld converts screen coordinates to world coordinates
 func (v *Viewport) ScreenToWorld(screenX, screenY float64) (float64, float64) {
-	worldX := screenX + v.x
-	worldY := screenY + v.y
+	camX, camY := v.camera()
+	worldX := utils.Wrap(screenX+camX, v.worldWidth)
+	worldY := screenY + camY
 	return worldX, worldY
 }
+
+// ParallaxX returns the horizontal scroll offset of a background layer that
+// moves at the given fraction of the camera speed. It is based on the
+// unwrapped scroll distance, so slow layers don't jump when the camera
+// crosses the world seam.
+func (v *Viewport) ParallaxX(factor float64) float64 {
+	scrollX := v.prevScrollX + (v.scrollX-v.prevScrollX)*v.alpha
+	return utils.Wrap(scrollX*factor, v.worldWidth)
+}
+
+// ParallaxY returns the vertical scroll offset of a background layer that
+// moves at the given fraction of the camera speed. Layers line up with the
+// world when the camera is at the bottom, where the ground is, and lag
+// behind as it climbs.
+func (v *Viewport) ParallaxY(factor float64) float64 {
+	_, camY := v.camera()
+	maxY := v.worldHeight - v.height
+	return maxY - (maxY-camY)*factor
+}
@@ -0,0 +1,48 @@
+package main
+
+const (
+	// Frames between scripted attack runs, before the sector speeds them up
+	waveInterval = 20 * 60
+	// Frames before the first attack run of a level
+	firstWaveDelay = 5 * 60
+)
+
+// waveDirector launches the scripted formation attack runs, cycling
+// through the sector's wave plan
+type waveDirector struct {
+	plan     []plannedWave
+	interval int // Frames to wait when the plan starts over
+	next     int // Index of the next planned wave to launch
+	timer    int // Frames until the next launch
+	wave     int // Number of attack runs launched so far
+}
+
+func newWaveDirector(plan []plannedWave, interval int) *waveDirector {
+	d := &waveDirector{plan: plan, interval: interval}
+	if len(plan) > 0 {
+		d.timer = plan[0].delay
+	}
+	return d
+}
+
+// Update counts down to the next attack run and spawns it into the world
+func (d *waveDirector) Update(world *World) {
+	if len(d.plan) == 0 {
+		return
+	}
+
+	d.timer--
+	if d.timer > 0 {
+		return
+	}
+
+	f := d.plan[d.next].formation
+	d.next = (d.next + 1) % len(d.plan)
+	d.timer = d.plan[d.next].delay
+	if d.next == 0 {
+		// The plan starts over, don't wait as long as the level's first wave
+		d.timer = d.interval
+	}
+	d.wave++
+	world.enemies = append(world.enemies, f.Spawn(world.formationKind(), world.player, world.viewport, world.level, world.library)...)
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	// Batches are drawn once they grow past this many vertices, well before
+	// they run out of uint16 indices
+	vectorFlushAt = 50000
+	// How far, in pixels, the pieces of a destroyed enemy fly apart
+	vectorShatter = 40
+	// Frames of motion a particle streak covers
+	vectorStreak = 2
+)
+
+// glowPass is one stroke of a line: a wide faint halo or the bright core
+type glowPass struct {
+	width float32
+	alpha float64
+}
+
+var (
+	vectorGlow     = []glowPass{{width: 7, alpha: 0.12}, {width: 3.5, alpha: 0.3}, {width: 1.5, alpha: 1}}
+	vectorParticle = []glowPass{{width: 4, alpha: 0.25}, {width: 1.5, alpha: 1}}
+)
+
+// wireframe is a shape made of closed outlines, with points given as
+// fractions of the sprite's box
+type wireframe [][][2]float64
+
+var (
+	shipWireframe = wireframe{
+		{{0, 0.2}, {0.3, 0.3}, {1, 0.55}, {0.3, 0.8}, {0, 0.9}, {0.1, 0.55}},
+		{{0.4, 0.38}, {0.65, 0.48}, {0.4, 0.5}},
+	}
+	memleakWireframe = wireframe{
+		{{0.5, 0}, {1, 0.5}, {0.5, 1}, {0, 0.5}},
+		{{0.5, 0.3}, {0.7, 0.5}, {0.5, 0.7}, {0.3, 0.5}},
+	}
+	crawlerWireframe = wireframe{
+		{{0.1, 0.4}, {0.9, 0.4}, {1, 0.8}, {0, 0.8}},
+		{{0.2, 0.8}, {0.3, 1}, {0.4, 0.8}},
+		{{0.6, 0.8}, {0.7, 1}, {0.8, 0.8}},
+	}
+	landerWireframe = wireframe{
+		{{0.3, 0.1}, {0.7, 0.1}, {0.85, 0.5}, {0.15, 0.5}},
+		{{0.15, 0.5}, {0, 0.95}, {0.2, 0.95}, {0.3, 0.5}},
+		{{0.7, 0.5}, {0.8, 0.95}, {1, 0.95}, {0.85, 0.5}},
+	}
+	mutantWireframe = wireframe{
+		{{0.5, 0}, {0.6, 0.35}, {1, 0.2}, {0.7, 0.5}, {1, 0.8}, {0.6, 0.65}, {0.5, 1}, {0.4, 0.65}, {0, 0.8}, {0.3, 0.5}, {0, 0.2}, {0.4, 0.35}},
+	}
+	gopherWireframe = wireframe{
+		{{0.15, 0.2}, {0.85, 0.2}, {0.9, 1}, {0.1, 1}},
+		{{0.15, 0.2}, {0.1, 0}, {0.35, 0.2}},
+		{{0.65, 0.2}, {0.9, 0}, {0.85, 0.2}},
+	}
+)
+
+// VectorRenderer draws the world as glowing line art, in the style of the
+// vector arcade machines of the early eighties
+type VectorRenderer struct {
+	screen *ebiten.Image
+	path   vector.Path
+
+	// Reused every frame to batch the lines into as few draw calls as possible
+	vertices []ebiten.Vertex
+	indices  []uint16
+}
+
+func NewVectorRenderer() *VectorRenderer {
+	return &VectorRenderer{}
+}
+
+func (r *VectorRenderer) Draw(screen *ebiten.Image, world *World) {
+	r.screen = screen
+	v, pal := world.viewport, world.library.Palette()
+	if !world.planetDestroyed {
+		mountains := world.mountains.outline
+		mountains.A = 140
+		r.drawTerrain(world.mountains, v, mountains)
+		r.drawTerrain(world.ground, v, world.ground.outline)
+	}
+	for _, g := range world.gophers {
+		if g.Alive() {
+			x, y := v.WorldToScreen(v.Lerp(g.prevX, g.prevY, g.x, g.y))
+			r.outline(gopherWireframe, x, y, gopherWidth, gopherHeight, false)
+			r.stroke(pal.Gopher.RGBA, vectorGlow)
+		}
+	}
+	r.drawHazards(world)
+	for _, e := range world.enemies {
+		r.drawEnemy(e)
+	}
+	r.drawPlayer(world.player, pal.Ship.RGBA)
+	r.drawParticles(world)
+	r.flush()
+	world.drawPlanetFlash(screen)
+}
+
+// outline adds a wireframe, stretched over a box on screen, to the path
+func (r *VectorRenderer) outline(shape wireframe, x, y, w, h float64, flip bool) {
+	for _, loop := range shape {
+		for i, pt := range loop {
+			px := pt[0]
+			if flip {
+				px = 1 - px
+			}
+			sx, sy := float32(x+px*w), float32(y+pt[1]*h)
+			if i == 0 {
+				r.path.MoveTo(sx, sy)
+			} else {
+				r.path.LineTo(sx, sy)
+			}
+		}
+		r.path.Close()
+	}
+}
+
+// stroke draws the current path as a glowing line and starts a new one
+func (r *VectorRenderer) stroke(clr color.RGBA, passes []glowPass) {
+	if len(r.vertices) > vectorFlushAt {
+		r.flush()
+	}
+	for _, pass := range passes {
+		start := len(r.vertices)
+		r.vertices, r.indices = r.path.AppendVerticesAndIndicesForStroke(r.vertices, r.indices, &vector.StrokeOptions{
+			Width:    pass.width,
+			LineJoin: vector.LineJoinRound,
+		})
+		c := clr
+		c.A = uint8(float64(clr.A) * pass.alpha)
+		colorVertices(r.vertices[start:], c)
+	}
+	r.path = vector.Path{}
+}
+
+// line draws a single glowing segment. It skips vector.Path, which is
+// worth it for the thousands of particles an explosion can throw out.
+func (r *VectorRenderer) line(x0, y0, x1, y1 float64, clr color.RGBA, passes []glowPass) {
+	if len(r.vertices) > vectorFlushAt {
+		r.flush()
+	}
+	l := math.Hypot(x1-x0, y1-y0)
+	if l == 0 {
+		return
+	}
+	// Unit vectors along the segment and across it
+	ux, uy := (x1-x0)/l, (y1-y0)/l
+	for _, pass := range passes {
+		half := float64(pass.width) / 2
+		nx, ny := -uy*half, ux*half
+		ax, ay := x0-ux*half, y0-uy*half
+		bx, by := x1+ux*half, y1+uy*half
+		c := clr
+		c.A = uint8(float64(clr.A) * pass.alpha)
+		r.vertices, r.indices = appendQuad(r.vertices, r.indices,
+			float32(ax+nx), float32(ay+ny), float32(bx+nx), float32(by+ny),
+			float32(bx-nx), float32(by-ny), float32(ax-nx), float32(ay-ny), c)
+	}
+}
+
+// flush draws the lines batched so far. They add up where they cross, the
+// way phosphor does.
+func (r *VectorRenderer) flush() {
+	if len(r.indices) > 0 {
+		r.screen.DrawTriangles(r.vertices, r.indices, whitePixel, &ebiten.DrawTrianglesOptions{
+			AntiAlias: true,
+			Blend:     ebiten.BlendLighter,
+		})
+	}
+	r.vertices = r.vertices[:0]
+	r.indices = r.indices[:0]
+}
+
+// drawTerrain traces the surface of a terrain layer
+func (r *VectorRenderer) drawTerrain(l *TerrainLayer, v *Viewport, clr color.RGBA) {
+	offsetX := v.ParallaxX(l.parallax)
+	offsetY := v.ParallaxY(l.parallax)
+	r.path.MoveTo(0, float32(v.worldHeight-l.terrain.HeightAt(offsetX)-offsetY))
+	for sx := terrainDrawStep; sx <= int(v.width)+terrainDrawStep; sx += terrainDrawStep {
+		r.path.LineTo(float32(sx), float32(v.worldHeight-l.terrain.HeightAt(offsetX+float64(sx))-offsetY))
+	}
+	r.stroke(clr, vectorGlow)
+}
+
+func (r *VectorRenderer) drawHazards(world *World) {
+	v, pal := world.viewport, world.library.Palette()
+	glow := pal.Well.RGBA
+	for _, w := range world.wells {
+		cx, cy := v.WorldToScreen(w.x, w.y)
+		if cx < -w.radius || cx > v.width+w.radius || cy < -w.radius || cy > v.height+w.radius {
+			continue
+		}
+		r.path.Arc(float32(cx), float32(cy), float32(w.core), 0, 2*math.Pi, vector.Clockwise)
+		r.stroke(glow, vectorGlow)
+		r.path.Arc(float32(cx), float32(cy), float32(w.radius), 0, 2*math.Pi, vector.Clockwise)
+		faint := glow
+		faint.A = 60
+		r.stroke(faint, vectorGlow[2:])
+
+		// Matter streaks into the core, faster the closer it gets
+		t := float64(world.ticks)
+		for i := range wellDots {
+			orbit := w.core + float64(i)/wellDots*(w.radius*0.6)
+			angle := float64(i)*2.4 + t*0.02*w.core/orbit*3
+			r.path.MoveTo(float32(cx+math.Cos(angle)*orbit), float32(cy+math.Sin(angle)*orbit))
+			r.path.Arc(float32(cx), float32(cy), float32(orbit), float32(angle), float32(angle+0.15), vector.Clockwise)
+		}
+		r.stroke(glow, vectorParticle)
+	}
+
+	for _, a := range world.asteroids {
+		cx, cy := v.WorldToScreen(v.Lerp(a.prevX, a.prevY, a.x, a.y))
+		if cx < -a.radius || cx > v.width+a.radius || cy < -a.radius || cy > v.height+a.radius {
+			continue
+		}
+		rotation := a.prevRotation + (a.rotation-a.prevRotation)*v.alpha
+		for i, s := range a.shape {
+			angle := rotation + float64(i)*2*math.Pi/asteroidPoints
+			x := float32(cx + math.Cos(angle)*a.radius*s)
+			y := float32(cy + math.Sin(angle)*a.radius*s)
+			if i == 0 {
+				r.path.MoveTo(x, y)
+			} else {
+				r.path.LineTo(x, y)
+			}
+		}
+		r.path.Close()
+	}
+	r.stroke(pal.AsteroidOutline.RGBA, vectorGlow)
+}
+
+// drawEnemy traces an enemy, or the pieces of one flying apart as it dies
+func (r *VectorRenderer) drawEnemy(e *Enemy) {
+	if !e.active || e.waiting() {
+		return
+	}
+	v := e.viewport
+	x, y := v.WorldToScreen(v.Lerp(e.prevX, e.prevY, e.x, e.y))
+	if x < -enemyWidth || x > v.width || y < -enemyHeight || y > v.height {
+		return
+	}
+	shape := e.kind.wireframe
+	if !e.exploding {
+		r.outline(shape, x, y, enemyWidth, enemyHeight, false)
+		r.stroke(e.kind.scannerColor, vectorGlow)
+		return
+	}
+
+	t := 1 - float64(e.dying)/explosionFrames
+	for _, loop := range shape {
+		for i, p0 := range loop {
+			p1 := loop[(i+1)%len(loop)]
+			// Every edge flies away from the middle of the box
+			dx, dy := (p0[0]+p1[0])/2-0.5, (p0[1]+p1[1])/2-0.5
+			if d := math.Hypot(dx, dy); d > 0 {
+				dx, dy = dx/d, dy/d
+			}
+			ox, oy := x+dx*t*vectorShatter, y+dy*t*vectorShatter
+			r.path.MoveTo(float32(ox+p0[0]*enemyWidth), float32(oy+p0[1]*enemyHeight))
+			r.path.LineTo(float32(ox+p1[0]*enemyWidth), float32(oy+p1[1]*enemyHeight))
+		}
+	}
+	clr := e.kind.scannerColor
+	clr.A = uint8(255 * (1 - t))
+	r.stroke(clr, vectorGlow)
+}
+
+func (r *VectorRenderer) drawPlayer(p *Player, clr color.RGBA) {
+	v := p.viewport
+	for _, b := range p.bullets {
+		if !b.active {
+			continue
+		}
+		headX, headY, behind := b.trailHead(v)
+		x, y := v.WorldToScreen(headX, headY)
+		if behind > 0 {
+			for i := range behind {
+				pt := b.trail.At(i)
+				px, py := v.WorldToScreen(pt.x, pt.y)
+				if i == 0 {
+					r.path.MoveTo(float32(px), float32(py))
+				} else {
+					r.path.LineTo(float32(px), float32(py))
+				}
+			}
+			r.path.LineTo(float32(x), float32(y))
+			trail := p.trailStyle.color(b.trailHue, 0)
+			trail.A = 160
+			r.stroke(trail, vectorParticle)
+		}
+
+		r.line(x-b.vx, y-b.vy, x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255}, vectorGlow)
+	}
+
+	// Blink during the grace period after losing a life
+	if p.invulnerable > 0 && (p.invulnerable/6)%2 == 0 {
+		return
+	}
+	x, y := v.WorldToScreen(v.Lerp(p.prevX, p.prevY, p.x, p.y))
+	r.outline(shipWireframe, x, y, shipWidth, shipHeight, p.facingLeft)
+	r.stroke(clr, vectorGlow)
+}
+
+// drawParticles draws every particle as a short streak along its motion
+func (r *VectorRenderer) drawParticles(world *World) {
+	v := world.viewport
+	for _, p := range world.particles.pool.Particles() {
+		x, y := v.WorldToScreen(v.Lerp(p.PrevX, p.PrevY, p.X, p.Y))
+		if x < 0 || x > v.width || y < 0 || y > v.height {
+			continue
+		}
+		// Slow particles still get a dot's worth of line
+		dx, dy := p.VX*vectorStreak, p.VY*vectorStreak
+		if l := math.Hypot(dx, dy); l < 1 {
+			size := p.CurrentSize() / 2
+			dx, dy = math.Cos(p.Rotation)*size, math.Sin(p.Rotation)*size
+		}
+		r.line(x-dx, y-dy, x, y, p.Color(), vectorParticle)
+	}
+}
@@ -1,91 +1,200 @@
 package main
 
 import (
-	"image/color"
+	"fmt"
 	"math"
 	"math/rand"
-	"time"
 
+	"github.com/fabiomsouto/dfndr/internal/particles"
+	"github.com/fabiomsouto/dfndr/internal/steering"
+	"github.com/fabiomsouto/dfndr/internal/utils"
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
 const (
 	WorldWidth = 10000
-	Stars      = 500
 	MaxEnemies = 20
+	Gophers    = 10
+
+	// One in this many ambient enemies is a lander, and one in this many of
+	// the rest is a ground crawler
+	landerOdds  = 3
+	crawlerOdds = 4
+	// How quickly ground crawlers follow the terrain, 0-1
+	crawlerGrip = 0.2
+
+	maxMultiplier = 8
+	// Frames the player has to make another kill to keep the multiplier going
+	chainFrames = 150
 )
 
 type World struct {
-	level    int
-	stars    []Star
-	player   *Player
-	enemies  []*Enemy
-	viewport *Viewport
+	level      int
+	sector     *Sector
+	background *Background
+	ground     *TerrainLayer // The planet surface things collide with
+	mountains  *TerrainLayer // Distant decorative range behind the surface
+	player     *Player
+	enemies    []*Enemy
+	flock      []steering.Agent // Reused every frame for enemy swarm behaviors
+	waves      *waveDirector
+	viewport   *Viewport
+	gophers    []*Gopher
+	asteroids  []*Asteroid
+	wells      []*GravityWell
+	score      int
+	multiplier int // Score multiplier, built up by chaining kills
+	chain      int // Frames left to make another kill before the multiplier resets
+	ticks      int
+	library    *AssetManager
+	particles  *ParticleSystem
+	thrust     *particles.Source // The ship's engine exhaust
+	rng        *rand.Rand        // Seeded by the sector, so asteroids break up the same way every time
+
+	// Reused every frame to batch hazard drawing
+	hazardVertices []ebiten.Vertex
+	hazardIndices  []uint16
+
+	planetDestroyed bool
+	planetFlash     int // Frames left of the flash when the planet blows up
 }
 
-type Star struct {
-	x, y           float32
-	radius         int // in pixels
-	color          color.RGBA
-	originalColor  color.RGBA // Store original color to prevent fading
-	parallaxFactor float64    // How much this star moves relative to the camera (0.0-1.0)
+func NewWorld(player *Player, viewport *Viewport, sector *Sector, library *AssetManager) *World {
+	fx := NewParticleSystem(library.Emitters())
+	world := &World{
+		level:      sector.level,
+		sector:     sector,
+		background: library.Background(sector.level, sector.seed, sector.height, sector.palette),
+		ground:     NewTerrainLayer(sector.ground, 1, sector.groundFill, sector.groundOutline),
+		mountains:  NewTerrainLayer(sector.mountains, 0.4, sector.mountainFill, sector.mountainOutline),
+		player:     player,
+		enemies:    make([]*Enemy, MaxEnemies),
+		flock:      make([]steering.Agent, 0, MaxEnemies),
+		waves:      newWaveDirector(sector.waves, sector.waveInterval),
+		viewport:   viewport,
+		gophers:    make([]*Gopher, len(sector.gophers)),
+		asteroids:  sector.asteroids,
+		wells:      sector.wells,
+		library:    library,
+		particles:  fx,
+		thrust:     fx.Source("thrust"),
+		rng:        rand.New(rand.NewSource(sector.seed)),
+		multiplier: 1,
+	}
+	world.applyPalette()
+	for i, x := range sector.gophers {
+		world.gophers[i] = NewGopher(x, world.GroundY(x+gopherWidth/2)-gopherHeight, viewport, library.Sprite(SpriteGopher))
+	}
+	for i := range world.enemies {
+		world.enemies[i] = world.newAmbientEnemy()
+	}
+	return world
 }
 
-func NewWorld(player *Player, viewport *Viewport, level int) *World {
-	stars := generateStars(Stars)
-	enemies := make([]*Enemy, MaxEnemies)
-	for i := range enemies {
-		x := float64(randInt(0, WorldWidth))
-		y := float64(randInt(0, ScreenHeight))
-		vx := (rand.Float64() * 2) - 1
-		vy := (rand.Float64() * 2) - 1
-		enemies[i] = NewEnemy(x, y, vx, vy, player, viewport, level)
-	}
-	return &World{
-		level:    level,
-		stars:    stars,
-		player:   player,
-		enemies:  enemies,
-		viewport: viewport,
-	}
-}
-
-func generateStars(n int) []Star {
-	stars := make([]Star, n)
-	for i := range n {
-		radius := randInt(1, 5)
-		baseColor := color.RGBA{
-			R: uint8(randInt(0, 255)),
-			G: uint8(randInt(0, 255)),
-			B: uint8(randInt(0, 255)),
-			A: 255,
-		}
-		// Larger stars appear closer and move faster
-		parallaxFactor := 0.2 + (float64(radius)/5.0)*0.8
-		stars[i] = Star{
-			x:              float32(randInt(0, WorldWidth)),
-			y:              float32(randInt(0, ScreenHeight)),
-			color:          baseColor,
-			originalColor:  baseColor,
-			radius:         radius,
-			parallaxFactor: parallaxFactor,
-		}
+// applyPalette colors the terrain, in the theme's colors where it has them
+// and the sector's own otherwise, and styles the bullet trails
+func (world *World) applyPalette() {
+	pal, s := world.library.Palette(), world.sector
+	world.player.trailStyle = pal.trailStyle()
+	world.ground.fill = pal.Ground.or(s.groundFill)
+	world.ground.outline = pal.GroundOutline.or(s.groundOutline)
+	world.mountains.fill = pal.Mountains.or(s.mountainFill)
+	world.mountains.outline = pal.MountainsOutline.or(s.mountainOutline)
+}
+
+// newAmbientEnemy creates a member of the ambient swarm at a random spot away from the player
+func (world *World) newAmbientEnemy() *Enemy {
+	x, y := world.spawnPosition()
+	vx := (rand.Float64() * 2) - 1
+	vy := (rand.Float64() * 2) - 1
+	kind := memleakKind
+	switch {
+	case world.planetDestroyed:
+		kind = mutantKind
+	case rand.Intn(landerOdds) == 0:
+		kind = landerKind
+	case rand.Intn(crawlerOdds) == 0:
+		kind = crawlerKind
+	}
+	return NewEnemy(x, y, vx, vy, kind, world.player, world.viewport, world.level, world.library)
+}
+
+// formationKind returns the kind of enemy scripted attack runs are flown by
+func (world *World) formationKind() *EnemyKind {
+	if world.planetDestroyed {
+		return mutantKind
 	}
-	return stars
+	return memleakKind
 }
 
 func (world *World) Update() {
-	stars := world.stars
-	currentTime := time.Now().UnixMilli()
-	for i := range stars {
-		oscillation := math.Sin(float64(i)*0.02 + float64(currentTime)*0.0005)
-		brightness := math.Abs(oscillation)
+	world.ticks++
+	if world.chain > 0 {
+		world.chain--
+		if world.chain == 0 {
+			world.multiplier = 1
+		}
+	}
+	world.updateHazards()
+	world.collidePlayer()
+	world.assignLanderTargets()
+	world.updateGophers()
+	if world.planetFlash > 0 {
+		world.planetFlash--
+	}
+
+	world.background.Update()
+	if world.player.detonate {
+		world.player.detonate = false
+		world.smartBomb()
+	}
+	world.updateEnemies()
+	world.updateExhaust()
+	world.particles.Update()
+}
+
+// Snapshot remembers where everything in the world is before a
+// simulation tick moves it
+func (world *World) Snapshot() {
+	for _, e := range world.enemies {
+		e.prevX, e.prevY = e.x, e.y
+	}
+	for _, g := range world.gophers {
+		g.prevX, g.prevY = g.x, g.y
+	}
+	for _, a := range world.asteroids {
+		a.prevX, a.prevY, a.prevRotation = a.x, a.y, a.rotation
+	}
+	world.particles.pool.Snapshot()
+}
+
+// updateExhaust puffs engine exhaust out of the back of the ship while it thrusts
+func (world *World) updateExhaust() {
+	p := world.player
+	if !p.thrusting {
+		return
+	}
+	x, heading := p.x, math.Pi
+	if p.facingLeft {
+		x, heading = p.x+shipWidth, 0
+	}
+	world.thrust.Emit(world.particles.pool, x, p.y+shipHeight/2, heading)
+}
 
-		// Interpolate using the original color values
-		stars[i].color.R = uint8(float64(stars[i].originalColor.R) * (0.8 + brightness*0.4))
-		stars[i].color.G = uint8(float64(stars[i].originalColor.G) * (0.8 + brightness*0.4))
-		stars[i].color.B = uint8(float64(stars[i].originalColor.B) * (0.8 + brightness*0.4))
+// spawnPosition picks a random spot above the ground that is at least a
+// view's width away from the player, measured across the world seam. That
+// keeps it off screen wherever the camera has the player, however wide the
+// window.
+func (world *World) spawnPosition() (float64, float64) {
+	playerX, playerY := world.player.Position()
+	minDistance := min(world.viewport.width, WorldWidth/2-enemyWidth)
+	for {
+		x := float64(randInt(0, WorldWidth))
+		y := float64(randInt(0, int(world.GroundY(x+enemyWidth/2))-enemyHeight))
+		if utils.WrapDistance(playerX, playerY, x, y, WorldWidth) >= minDistance {
+			return x, y
+		}
 	}
 }
 
@@ -93,37 +202,117 @@ func randInt(min, max int) int {
 	return min + rand.Intn(max-min)
 }
 
-func (world *World) Draw(screen *ebiten.Image) {
-	updateStars(world, screen)
-	updateEnemies(world, screen)
+// DrawBackground draws the background layers behind the action
+func (world *World) DrawBackground(screen *ebiten.Image) {
+	world.background.DrawBack(screen, world.viewport)
+}
+
+// DrawLit draws what the lights fall on: the terrain, gophers, hazards and
+// enemies
+func (world *World) DrawLit(screen *ebiten.Image) {
+	if !world.planetDestroyed {
+		world.mountains.Draw(screen, world.viewport)
+		world.ground.Draw(screen, world.viewport)
+	}
+	for _, g := range world.gophers {
+		g.Draw(screen)
+	}
+	world.drawHazards(screen)
+	for _, enemy := range world.enemies {
+		enemy.Draw(screen, world.viewport)
+	}
+}
+
+// DrawEffects draws the particles and the flash of the planet blowing up,
+// which shine with their own light
+func (world *World) DrawEffects(screen *ebiten.Image) {
+	world.particles.Draw(screen, world.viewport)
+	world.drawPlanetFlash(screen)
+}
+
+// DrawForeground draws the background layers that pass in front of the action
+func (world *World) DrawForeground(screen *ebiten.Image) {
+	world.background.DrawFront(screen, world.viewport)
+}
+
+// GroundY returns the world y coordinate of the planet surface at x. Once
+// the planet is gone, that's the bottom of the world.
+func (world *World) GroundY(x float64) float64 {
+	if world.planetDestroyed {
+		return world.sector.height
+	}
+	return world.sector.groundY(x)
 }
 
-func updateStars(world *World, screen *ebiten.Image) {
-	for _, star := range world.stars {
-		// Apply parallax effect by scaling the viewport offset
-		parallaxX := world.viewport.x * star.parallaxFactor
-		screenX := float64(star.x) - parallaxX
-		screenY := float64(star.y) - world.viewport.y
+// collidePlayer stops the ship from flying into the ground
+func (world *World) collidePlayer() {
+	if world.planetDestroyed {
+		return
+	}
+	p := world.player
+	surface := world.sector.height - world.ground.terrain.MaxHeightIn(p.x, shipWidth)
+	if p.y+shipHeight > surface {
+		p.y = surface - shipHeight
+		if p.vy > 0 {
+			p.vy = 0
+		}
+	}
+}
 
-		// Wrap stars horizontally based on their parallax speed
-		if screenX < -float64(star.radius) {
-			screenX += WorldWidth
-		} else if screenX > world.viewport.width+float64(star.radius) {
-			screenX -= WorldWidth
+// collideEnemy keeps an enemy above the ground, and glues ground crawlers to it
+func (world *World) collideEnemy(enemy *Enemy) {
+	if !enemy.active || enemy.exploding || world.planetDestroyed {
+		return
+	}
+	surface := world.sector.height - world.ground.terrain.MaxHeightIn(enemy.x, enemyWidth)
+	if hover := enemy.kind.groundHover; hover > 0 {
+		target := surface - hover - enemyHeight
+		enemy.y += (target - enemy.y) * crawlerGrip
+		enemy.vy = 0
+	} else if enemy.y+enemyHeight > surface {
+		enemy.y = surface - enemyHeight
+		if enemy.vy > 0 {
+			enemy.vy = 0
 		}
+	}
+}
 
-		// Only draw stars that are within the viewport
-		if screenX >= -float64(star.radius) && screenX <= world.viewport.width+float64(star.radius) &&
-			screenY >= -float64(star.radius) && screenY <= world.viewport.height+float64(star.radius) {
-			vector.DrawFilledRect(screen, float32(screenX), float32(screenY), float32(star.radius), float32(star.radius), star.color, false)
+// alertAllies tells enemies near a destroyed one about its demise
+func (world *World) alertAllies(fallen *Enemy) {
+	for _, enemy := range world.enemies {
+		if enemy == fallen || !enemy.active || enemy.exploding {
+			continue
+		}
+		if utils.WrapDistance(enemy.x, enemy.y, fallen.x, fallen.y, WorldWidth) < allyAlarmRadius {
+			enemy.brain.Send(enemy, aiEventAllyDown)
 		}
 	}
 }
 
-func updateEnemies(world *World, screen *ebiten.Image) {
+// DrawDebug overlays debugging information about the world
+func (world *World) DrawDebug(screen *ebiten.Image) {
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("sector %d: %s, effects %s, theme %s", world.level, world.sector.biome.name, world.viewport.effects, world.library.Theme()), 8, int(world.viewport.height)-20)
+	for _, enemy := range world.enemies {
+		enemy.DrawDebug(screen)
+	}
+}
+
+func (world *World) updateEnemies() {
+	// Launch scripted attack runs
+	world.waves.Update(world)
+
+	// Snapshot the swarm so every enemy steers against the same state
+	world.flock = world.flock[:0]
+	for _, enemy := range world.enemies {
+		if enemy.active && !enemy.exploding && !enemy.waiting() {
+			world.flock = append(world.flock, enemy.agent())
+		}
+	}
+
 	// First update all enemies
 	for _, enemy := range world.enemies {
-		enemy.Update()
+		enemy.Update(world.flock)
+		world.collideEnemy(enemy)
 	}
 
 	// Check for bullet collisions with enemies
@@ -132,26 +321,79 @@ func updateEnemies(world *World, screen *ebiten.Image) {
 			for _, enemy := range world.enemies {
 				if enemy.CheckBulletCollision(bullet.x, bullet.y) {
 					bullet.active = false // Deactivate bullet on hit
-					break                 // Exit inner loop since bullet can only hit one enemy
+					if enemy.exploding {
+						world.enemyKilled(enemy)
+						world.alertAllies(enemy)
+					}
+					break // Exit inner loop since bullet can only hit one enemy
 				}
 			}
 		}
 	}
 
-	// Respawn inactive enemies
-	for i, enemy := range world.enemies {
+	// Respawn inactive enemies of the ambient swarm, and drop those from attack runs
+	alive := world.enemies[:0]
+	for _, enemy := range world.enemies {
 		if !enemy.active {
-			// Create a new enemy at a random position
-			x := float64(randInt(0, WorldWidth))
-			y := float64(randInt(0, ScreenHeight))
-			vx := (rand.Float64() * 2) - 1
-			vy := (rand.Float64() * 2) - 1
-			world.enemies[i] = NewEnemy(x, y, vx, vy, world.player, world.viewport, world.level)
+			if enemy.formation != nil {
+				continue
+			}
+			enemy = world.newAmbientEnemy()
 		}
+		alive = append(alive, enemy)
+	}
+	world.enemies = alive
+}
+
+// addScore awards points, boosted by the multiplier
+func (world *World) addScore(points int) {
+	world.score += points * world.multiplier
+}
+
+// enemyKilled scores a kill, keeps the chain going and rattles the camera,
+// more so for the big ones
+func (world *World) enemyKilled(enemy *Enemy) {
+	world.explode(enemy)
+	world.addScore(enemy.kind.points)
+	world.multiplier = min(maxMultiplier, world.multiplier+1)
+	world.chain = chainFrames
+	world.viewport.AddTrauma(killTrauma)
+	if enemy.kind.big {
+		world.viewport.HitStop(bigKillStop)
 	}
+}
 
-	// Draw all active enemies
+// explode sets off the explosion of a destroyed enemy
+func (world *World) explode(enemy *Enemy) {
+	world.particles.Burst("explosion", enemy.x+enemyWidth/2, enemy.y+enemyHeight/2, 0)
+}
+
+// smartBomb destroys every enemy and asteroid on screen
+func (world *World) smartBomb() {
+	v := world.viewport
+	onScreen := func(x, y float64) bool {
+		sx, sy := v.WorldToScreen(x, y)
+		return sx >= 0 && sx <= v.width && sy >= 0 && sy <= v.height
+	}
 	for _, enemy := range world.enemies {
-		enemy.Draw(screen, world.viewport)
+		if !enemy.active || enemy.exploding || enemy.waiting() || !onScreen(enemy.x+enemyWidth/2, enemy.y+enemyHeight/2) {
+			continue
+		}
+		for !enemy.exploding {
+			enemy.Hit()
+		}
+		world.explode(enemy)
+		world.addScore(enemy.kind.points)
+	}
+	for _, a := range world.asteroids {
+		if a.active && onScreen(a.x, a.y) {
+			a.active = false
+			world.particles.Burst("debris", a.x, a.y, 0)
+			world.addScore(asteroidScore)
+		}
 	}
+	p := world.player
+	world.particles.Burst("shockwave", p.x+shipWidth/2, p.y+shipHeight/2, 0)
+	v.AddTrauma(bombTrauma)
+	v.HitStop(bombStop)
 }